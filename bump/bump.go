@@ -0,0 +1,182 @@
+// Package bump renders 2D bump mapping with moving lights.
+//
+// The slope of a height map is calculated once for each pixel.
+// When rendering, the slope is added to the offset from the pixel to the light,
+// which is looked up in a light map, so lighting a pixel is only additions
+// and a table lookup.
+// Pixels facing the light find the bright center of the light map,
+// while pixels facing away find the dark edge.
+package bump
+
+import (
+	"errors"
+	"image"
+	"math"
+	"math/bits"
+
+	"github.com/klauspost/gad/lissajous"
+)
+
+// Light is a moving point light.
+// The center of its curve is relative to the screen center and Z is not used.
+type Light = lissajous.Curve
+
+// Config describes a bump mapping effect.
+type Config struct {
+	Lights []Light
+
+	// LightMap is the brightness around a light, with the light in the center.
+	// It must be square with a power of two size.
+	// If nil, Spot(256) is used.
+	LightMap *image.Gray
+
+	// Depth scales the height map, up to ±MaxDepth. 0 gives 1.
+	Depth float64
+}
+
+// MaxDepth is the largest Depth, where the steepest slope still fits in 16 bits.
+const MaxDepth = 128
+
+// Fx is a bump mapping effect.
+type Fx struct {
+	cfg Config
+	// Slope of each pixel, added to the light offset.
+	slopeX, slopeY []int16
+	light          []byte
+	logLight       uint
+	halfLight      int
+	// Offset into the light map of the top left pixel for each light.
+	lightU, lightV []int
+	// Sum of the lights on a line. It is an int, so many lights can't wrap it.
+	acc   []int
+	draw  *image.Gray
+	lines [][]byte
+	w, h  int
+}
+
+// New returns a bump mapping effect rendering w * h pixels.
+// The height map is repeated if it is smaller than the output.
+func New(w, h int, height *image.Gray, c Config) (*Fx, error) {
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("bump: invalid size")
+	}
+	hw, hh := height.Rect.Dx(), height.Rect.Dy()
+	if hw <= 0 || hh <= 0 {
+		return nil, errors.New("bump: empty height map")
+	}
+	if c.LightMap == nil {
+		c.LightMap = Spot(256)
+	}
+	size := c.LightMap.Rect.Dx()
+	if size != c.LightMap.Rect.Dy() || size <= 0 || size&(size-1) != 0 {
+		return nil, errors.New("bump: light map must be square with a power of two size")
+	}
+	if c.Depth == 0 {
+		c.Depth = 1
+	}
+	if c.Depth > MaxDepth || c.Depth < -MaxDepth {
+		return nil, errors.New("bump: depth out of range")
+	}
+	fx := Fx{
+		cfg:    c,
+		w:      w,
+		h:      h,
+		lightU: make([]int, len(c.Lights)),
+		lightV: make([]int, len(c.Lights)),
+	}
+
+	// Copy the light map, so it has no stride.
+	fx.logLight = uint(bits.Len32(uint32(size))) - 1
+	fx.halfLight = size / 2
+	fx.light = make([]byte, size*size)
+	for y := 0; y < size; y++ {
+		copy(fx.light[y*size:(y+1)*size], c.LightMap.Pix[y*c.LightMap.Stride:])
+	}
+
+	// Slope is the difference of the neighbors.
+	at := func(x, y int) int {
+		x, y = x%hw, y%hh
+		if x < 0 {
+			x += hw
+		}
+		if y < 0 {
+			y += hh
+		}
+		return int(height.Pix[y*height.Stride+x])
+	}
+	fx.slopeX = make([]int16, w*h)
+	fx.slopeY = make([]int16, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx.slopeX[y*w+x] = int16(float64(at(x-1, y)-at(x+1, y)) * c.Depth)
+			fx.slopeY[y*w+x] = int16(float64(at(x, y-1)-at(x, y+1)) * c.Depth)
+		}
+	}
+
+	// Create our draw buffer
+	fx.draw = image.NewGray(image.Rect(0, 0, w, h))
+
+	// Store each line as a slice in a slice.
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+	fx.acc = make([]int, w)
+	return &fx, nil
+}
+
+// Spot returns a size * size light map, bright in the center
+// and falling off towards the edges.
+func Spot(size int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	half := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := (float64(x)+0.5-half)/half, (float64(y)+0.5-half)/half
+			d := 1 - math.Sqrt(dx*dx+dy*dy)
+			if d <= 0 {
+				continue
+			}
+			// Smooth falloff with a small highlight in the center.
+			v := 200*d*d + 55*math.Pow(d, 16)
+			img.Pix[y*img.Stride+x] = uint8(v)
+		}
+	}
+	return img
+}
+
+// Render the effect at time t.
+func (fx *Fx) Render(t float64) image.Image {
+	size := 1 << fx.logLight
+	for i, l := range fx.cfg.Lights {
+		lx, ly, _ := l.At(t)
+		fx.lightU[i] = fx.halfLight - fx.w/2 - int(math.Floor(lx))
+		fx.lightV[i] = fx.halfLight - fx.h/2 - int(math.Floor(ly))
+	}
+	for y, line := range fx.lines {
+		acc := fx.acc
+		for x := range acc {
+			acc[x] = 0
+		}
+		slopeX := fx.slopeX[y*fx.w : (y+1)*fx.w]
+		slopeY := fx.slopeY[y*fx.w : (y+1)*fx.w]
+		for i := range fx.cfg.Lights {
+			u0, v := fx.lightU[i], fx.lightV[i]+y
+			for x, sx := range slopeX {
+				u := u0 + x + int(sx)
+				v := v + int(slopeY[x])
+				if uint(u) >= uint(size) || uint(v) >= uint(size) {
+					continue
+				}
+				acc[x] += int(fx.light[v<<fx.logLight+u])
+			}
+		}
+		for x, v := range acc {
+			if v > 255 {
+				v = 255
+			}
+			line[x] = uint8(v)
+		}
+	}
+	return fx.draw
+}
@@ -0,0 +1,54 @@
+package bump
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDepthRange(t *testing.T) {
+	height := image.NewGray(image.Rect(0, 0, 4, 4))
+	for _, d := range []float64{-MaxDepth, -1, 0, 0.5, MaxDepth} {
+		if _, err := New(8, 8, height, Config{Depth: d}); err != nil {
+			t.Errorf("depth %v: %v", d, err)
+		}
+	}
+	for _, d := range []float64{-MaxDepth - 1, MaxDepth + 0.5, 1000} {
+		if _, err := New(8, 8, height, Config{Depth: d}); err == nil {
+			t.Errorf("depth %v: want error", d)
+		}
+	}
+}
+
+func TestSteepSlope(t *testing.T) {
+	// Neighbors differ by 255, the steepest slope there is.
+	height := image.NewGray(image.Rect(0, 0, 4, 1))
+	copy(height.Pix, []uint8{0, 0, 255, 255})
+	fx, err := New(4, 4, height, Config{Depth: MaxDepth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range fx.slopeX {
+		if s != 255*MaxDepth && s != -255*MaxDepth {
+			t.Fatalf("slope %d: got %d, want ±%d", i, s, 255*MaxDepth)
+		}
+	}
+}
+
+func TestManyLights(t *testing.T) {
+	// Enough lights in the center to wrap 16 bits.
+	const w, h = 8, 8
+	lights := make([]Light, 300)
+	fx, err := New(w, h, image.NewGray(image.Rect(0, 0, 1, 1)), Config{Lights: lights})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := fx.Render(0).(*image.Gray)
+	for i, v := range img.Pix {
+		if v != 255 {
+			t.Fatalf("pixel %d: got %d, want 255", i, v)
+		}
+	}
+	if n := testing.AllocsPerRun(10, func() { fx.Render(0.5) }); n != 0 {
+		t.Errorf("Render allocates %v times", n)
+	}
+}
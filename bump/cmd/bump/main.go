@@ -0,0 +1,41 @@
+package main
+
+import (
+	"image/color"
+	_ "image/png"
+
+	"github.com/klauspost/gad/bump"
+	_ "github.com/klauspost/gad/dentro/data" // Load textures.
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+func main() {
+	gfx.InitShadedPalette(192, color.RGBA{R: 240, G: 200, B: 140})
+	height, err := gfx.LoadGreyPicture("data/wildtextures-african-grey.png")
+	if err != nil {
+		panic(err)
+	}
+	light, err := gfx.LoadGreyPicture("data/light.png")
+	if err != nil {
+		panic(err)
+	}
+	fx, err := bump.New(renderWidth, renderHeight, height, bump.Config{
+		Lights: []bump.Light{
+			{AmpX: 220, AmpY: 110, FreqX: 1, FreqY: 2},
+			{AmpX: 160, AmpY: 130, FreqX: 2, FreqY: 1, Phase: 0.4},
+		},
+		LightMap: light,
+		// Generated light:
+		//LightMap: bump.Spot(256),
+		Depth: 0.5,
+	})
+	if err != nil {
+		panic(err)
+	}
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
@@ -0,0 +1,117 @@
+package copper
+
+import (
+	"image/color"
+	"math"
+)
+
+// Behind sets the background color of line y.
+// Entries below cut fade from col to the entry at cut,
+// so dark pixels show the color and bright pixels are kept.
+// If cut is 0, only entry 0 is changed.
+func (c *Fx) Behind(y int, col color.RGBA, cut uint8) {
+	if y < 0 || y >= c.h {
+		return
+	}
+	pal := &c.Lines[y]
+	if cut == 0 {
+		pal[0] = col
+		return
+	}
+	to := pal[cut]
+	n := int(cut)
+	for i := 0; i < n; i++ {
+		pal[i] = color.RGBA{
+			R: uint8((int(col.R)*(n-i) + int(to.R)*i) / n),
+			G: uint8((int(col.G)*(n-i) + int(to.G)*i) / n),
+			B: uint8((int(col.B)*(n-i) + int(to.B)*i) / n),
+			A: 255,
+		}
+	}
+}
+
+// Tint multiplies all entries of line y with col.
+func (c *Fx) Tint(y int, col color.RGBA) {
+	if y < 0 || y >= c.h {
+		return
+	}
+	pal := &c.Lines[y]
+	for i, v := range pal {
+		pal[i] = color.RGBA{
+			R: uint8(int(v.R) * int(col.R) / 255),
+			G: uint8(int(v.G) * int(col.G) / 255),
+			B: uint8(int(v.B) * int(col.B) / 255),
+			A: 255,
+		}
+	}
+}
+
+// RasterBar draws a bar from line y and height lines down behind the picture.
+// The bar is brightest in the middle and dark at the edges.
+// See Behind for cut.
+func (c *Fx) RasterBar(y, height int, col color.RGBA, cut uint8) {
+	for i := 0; i < height; i++ {
+		s := math.Sin(math.Pi * (float64(i) + 0.5) / float64(height))
+		c.Behind(y+i, scale(col, s), cut)
+	}
+}
+
+// Bar is a raster bar moving up and down on a sine.
+type Bar struct {
+	Color color.RGBA
+
+	// Height of the bar in lines.
+	Height int
+
+	// Center of the movement, relative to the screen center.
+	Y float64
+
+	// Amplitude of the movement in lines.
+	Amp float64
+
+	// Freq is the number of times the bar moves up and down per unit of time.
+	Freq float64
+
+	// Phase delays the bar, in periods, so bars with the same
+	// frequency can follow each other.
+	Phase float64
+}
+
+// At returns the center of the bar at time t, relative to the screen center.
+func (b Bar) At(t float64) float64 {
+	return b.Y + b.Amp*math.Sin((b.Freq*t+b.Phase)*2*math.Pi)
+}
+
+// SineBars draws the bars at time t.
+// Later bars are drawn on top of earlier bars.
+// See Behind for cut.
+func (c *Fx) SineBars(t float64, cut uint8, bars ...Bar) {
+	for _, b := range bars {
+		y := c.h/2 + int(math.Floor(b.At(t))) - b.Height/2
+		c.RasterBar(y, b.Height, b.Color, cut)
+	}
+}
+
+// Sky draws a gradient from top to bottom behind the picture.
+// See Behind for cut.
+func (c *Fx) Sky(top, bottom color.RGBA, cut uint8) {
+	for y := 0; y < c.h; y++ {
+		f := float64(y) / float64(c.h)
+		c.Behind(y, color.RGBA{
+			R: uint8(float64(top.R) + (float64(bottom.R)-float64(top.R))*f),
+			G: uint8(float64(top.G) + (float64(bottom.G)-float64(top.G))*f),
+			B: uint8(float64(top.B) + (float64(bottom.B)-float64(top.B))*f),
+			A: 255,
+		}, cut)
+	}
+}
+
+// scale returns col multiplied with f, 0 -> 1.
+func scale(col color.RGBA, f float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(col.R) * f),
+		G: uint8(float64(col.G) * f),
+		B: uint8(float64(col.B) * f),
+		A: 255,
+	}
+}
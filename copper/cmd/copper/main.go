@@ -0,0 +1,44 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/klauspost/gad/copper"
+	"github.com/klauspost/gad/fire"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+// Heat below cut shows the copper colors.
+const cut = 32
+
+var bars = []copper.Bar{
+	{Color: color.RGBA{R: 255, G: 60, B: 40, A: 255}, Height: 24, Amp: 120, Freq: 3},
+	{Color: color.RGBA{R: 255, G: 200, B: 40, A: 255}, Height: 24, Amp: 120, Freq: 3, Phase: 0.08},
+	{Color: color.RGBA{R: 60, G: 220, B: 80, A: 255}, Height: 24, Amp: 120, Freq: 3, Phase: 0.16},
+	{Color: color.RGBA{R: 40, G: 120, B: 255, A: 255}, Height: 24, Amp: 120, Freq: 3, Phase: 0.24},
+}
+
+func main() {
+	img := image.NewGray(image.Rect(0, 0, renderWidth, renderHeight))
+	flames := fire.NewGray(img, fire.Default)
+	c, err := copper.New(renderWidth, renderHeight, fire.Palette)
+	if err != nil {
+		panic(err)
+	}
+	fx := c.Effect(flames, func(c *copper.Fx, t float64) {
+		c.Reset()
+		c.Sky(color.RGBA{B: 40, A: 255}, color.RGBA{R: 90, G: 20, B: 80, A: 255}, cut)
+		c.SineBars(t, cut, bars...)
+		// Wavy flames by shifting the palette of each line:
+		//for y := range c.Offsets {
+		//	c.Offsets[y] = uint8(8 + 8*math.Sin(float64(y)/20+t*40))
+		//}
+	})
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
@@ -0,0 +1,127 @@
+// Package copper converts grey images to color with a palette for each line.
+//
+// On the Amiga the copper changed color registers while the screen was drawn,
+// so each line could have its own colors.
+// Here each line has its own palette, and an offset added to the grey
+// values of the line before the lookup.
+// Raster bars and skies are made by changing the darkest palette entries,
+// so they appear behind the bright parts of any grey effect.
+package copper
+
+import (
+	"errors"
+	"image"
+	"image/color"
+
+	"github.com/klauspost/gfx"
+)
+
+// Fx converts grey images to RGBA using line palettes.
+type Fx struct {
+	// Lines has the palette of each line.
+	// Reset copies Base to all lines.
+	Lines [][256]color.RGBA
+
+	// Offsets are added to the grey values of each line, wrapping at 256.
+	Offsets []uint8
+
+	// Base is the palette before any changes.
+	Base [256]color.RGBA
+
+	dst  *image.RGBA
+	grey *image.Gray
+	w, h int
+}
+
+// New returns line palettes for a w * h screen.
+// The base palette is stretched to 256 entries.
+func New(w, h int, base color.Palette) (*Fx, error) {
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("copper: invalid size")
+	}
+	if len(base) == 0 {
+		return nil, errors.New("copper: empty palette")
+	}
+	c := Fx{
+		Lines:   make([][256]color.RGBA, h),
+		Offsets: make([]uint8, h),
+		dst:     image.NewRGBA(image.Rect(0, 0, w, h)),
+		w:       w,
+		h:       h,
+	}
+	for i := range c.Base {
+		c.Base[i] = color.RGBAModel.Convert(base[i*len(base)/256]).(color.RGBA)
+	}
+	c.Reset()
+	return &c, nil
+}
+
+// Reset sets all lines to the base palette with no offset.
+func (c *Fx) Reset() {
+	for y := range c.Lines {
+		c.Lines[y] = c.Base
+		c.Offsets[y] = 0
+	}
+}
+
+// Transfer converts src to color using the line palettes.
+// *image.Gray and *image.Paletted are converted directly,
+// using the pixel values as grey.
+// Other images are converted to grey first.
+// src must be at least as large as the screen.
+func (c *Fx) Transfer(src image.Image) *image.RGBA {
+	var pix []byte
+	var stride int
+	switch img := src.(type) {
+	case *image.Gray:
+		pix, stride = img.Pix, img.Stride
+	case *image.Paletted:
+		pix, stride = img.Pix, img.Stride
+	default:
+		if c.grey == nil {
+			c.grey = image.NewGray(image.Rect(0, 0, c.w, c.h))
+		}
+		b := src.Bounds()
+		for y := 0; y < c.h; y++ {
+			for x := 0; x < c.w; x++ {
+				c.grey.Set(x, y, src.At(b.Min.X+x, b.Min.Y+y))
+			}
+		}
+		pix, stride = c.grey.Pix, c.grey.Stride
+	}
+	dst := c.dst
+	for y := 0; y < c.h; y++ {
+		line := pix[y*stride : y*stride+c.w]
+		dLine := dst.Pix[y*dst.Stride : y*dst.Stride+c.w*4]
+		pal := &c.Lines[y]
+		off := c.Offsets[y]
+		for x, v := range line {
+			col := pal[v+off]
+			dLine[x*4] = col.R
+			dLine[x*4+1] = col.G
+			dLine[x*4+2] = col.B
+			dLine[x*4+3] = 255
+		}
+	}
+	return dst
+}
+
+// Effect returns an effect rendering fx and converting it with the line palettes.
+// Before each conversion setup is called to change the line palettes for time t.
+func (c *Fx) Effect(fx gfx.TimedEffect, setup func(c *Fx, t float64)) gfx.TimedEffect {
+	return &effect{c: c, fx: fx, setup: setup}
+}
+
+type effect struct {
+	c     *Fx
+	fx    gfx.TimedEffect
+	setup func(c *Fx, t float64)
+}
+
+func (e *effect) Render(t float64) image.Image {
+	img := e.fx.Render(t)
+	if e.setup != nil {
+		e.setup(e.c, t)
+	}
+	return e.c.Transfer(img)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"image/color"
+	_ "image/png"
+
+	"github.com/klauspost/gad/crawl"
+	_ "github.com/klauspost/gad/dentro/data" // Load font.
+	"github.com/klauspost/gad/dentro/screen"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+const text = `Episode VII
+
+GO AFTER DARK
+
+It is a period of demo coding.
+Gophers, working from hidden
+basements, have won their
+first compo against the evil
+empire of shader toys.
+
+During the party, gopher
+coders managed to steal the
+secret of the copper list,
+a technique with enough
+power to recolor an entire
+screen, one line at a time.`
+
+func main() {
+	gfx.InitShadedPalette(224, color.RGBA{R: 255, G: 220, B: 60})
+	font, err := screen.LoadFont("data/dosfont.png")
+	if err != nil {
+		panic(err)
+	}
+	fx, err := crawl.New(renderWidth, renderHeight, font, crawl.Config{
+		Text: text,
+		// Wider text with a lower horizon:
+		//Width:   1,
+		//Horizon: 0.3,
+	})
+	if err != nil {
+		panic(err)
+	}
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
@@ -0,0 +1,219 @@
+// Package crawl renders text crawling away on a tilted plane,
+// like the opening of Star Wars.
+//
+// The text is drawn with the 8x16 font of dentro/screen into a texture.
+// Like the rotozoomer of ep03, each screen line is a straight line in the texture,
+// so only the start and the step are calculated per line.
+// Lines further away use smaller mipmap levels and fade out towards the horizon.
+package crawl
+
+import (
+	"errors"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/klauspost/gad/dentro/screen"
+	"github.com/klauspost/gad/mipmap"
+)
+
+// Config describes a text crawl.
+type Config struct {
+	// Text with lines separated by '\n'. Each line is centered.
+	Text string
+
+	// Columns is the width of the text in characters.
+	// If 0, the longest line is used.
+	Columns int
+
+	// Width is the fraction of the screen width covered by the text
+	// at the bottom of the screen. 0 gives 0.8.
+	Width float64
+
+	// Horizon is the fraction of the screen height above the horizon. 0 gives 0.2.
+	Horizon float64
+
+	// Fade is the distance where the text has faded out,
+	// relative to the distance of the bottom of the screen. 0 gives 6.
+	Fade float64
+
+	// Speed is the number of texture pixels scrolled per unit of time.
+	// If 0, the text scrolls from below the screen until it has faded out
+	// once per unit of time.
+	Speed float64
+
+	// Color of the text. 0 gives 255.
+	Color uint8
+
+	// Background of the screen.
+	Background uint8
+}
+
+// Fx is a text crawl.
+type Fx struct {
+	cfg Config
+	// Texture with the text and its mipmaps.
+	mips         []*image.Gray
+	textW, textH int
+	horizon      float64
+	// Distance from the horizon to the bottom of the screen in pixels.
+	bottom float64
+	// Texture pixels per screen pixel at the bottom of the screen.
+	uBottom float64
+	draw    *image.Gray
+	lines   [][]byte
+	w, h    int
+}
+
+// New returns a text crawl rendering w * h pixels with font.
+// Use screen.LoadFont or the font of a screen.Fx.
+func New(w, h int, font *screen.Font, c Config) (*Fx, error) {
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("crawl: invalid size")
+	}
+	if font == nil {
+		return nil, errors.New("crawl: no font")
+	}
+	rows := strings.Split(c.Text, "\n")
+	if c.Columns <= 0 {
+		for _, row := range rows {
+			if len(row) > c.Columns {
+				c.Columns = len(row)
+			}
+		}
+	}
+	if c.Columns == 0 {
+		return nil, errors.New("crawl: no text")
+	}
+	if c.Width == 0 {
+		c.Width = 0.8
+	}
+	if c.Horizon == 0 {
+		c.Horizon = 0.2
+	}
+	if c.Horizon < 0 || c.Horizon >= 1 {
+		return nil, errors.New("crawl: horizon must be inside the screen")
+	}
+	if c.Fade == 0 {
+		c.Fade = 6
+	}
+	if c.Fade <= 1 {
+		return nil, errors.New("crawl: fade must be above 1")
+	}
+	if c.Color == 0 {
+		c.Color = 255
+	}
+	fx := Fx{cfg: c, w: w, h: h}
+	fx.textW, fx.textH = c.Columns*8, len(rows)*16
+	fx.horizon = c.Horizon * float64(h)
+	fx.bottom = float64(h) - fx.horizon
+	fx.uBottom = float64(fx.textW) / (c.Width * float64(w))
+
+	// Power of two sizes, so all mipmap levels are exactly half.
+	tex := image.NewGray(image.Rect(0, 0, pow2(fx.textW), pow2(fx.textH)))
+	for i, row := range rows {
+		x0 := (fx.textW - len(row)*8) / 2
+		for j := 0; j < len(row); j++ {
+			glyph := &font[row[j]&127]
+			for y, bits := range glyph {
+				for x := 0; x < 8; x++ {
+					px := x0 + j*8 + x
+					if bits<<uint(x)&0x80 == 0 || px < 0 || px >= tex.Rect.Dx() {
+						continue
+					}
+					tex.Pix[(i*16+y)*tex.Stride+px] = 255
+				}
+			}
+		}
+	}
+	fx.mips = mipmap.Gray(tex, mipmap.Box)
+
+	// Create our draw buffer
+	fx.draw = image.NewGray(image.Rect(0, 0, w, h))
+
+	// Store each line as a slice in a slice.
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+	return &fx, nil
+}
+
+// Render the effect at time t.
+func (fx *Fx) Render(t float64) image.Image {
+	const (
+		DecimalPointLog = 16
+		DecimalMul      = 1 << DecimalPointLog
+	)
+	cfg := &fx.cfg
+	bg := cfg.Background
+
+	// Texture lines per distance unit, so pixels are square at the bottom.
+	vScale := fx.uBottom * fx.bottom
+	speed := cfg.Speed
+	if speed == 0 {
+		// Until the last line is at the fade distance.
+		speed = float64(fx.textH) + vScale*(cfg.Fade-1)
+	}
+	// Texture line at the bottom of the screen.
+	scroll := t * speed
+
+	for y, line := range fx.lines {
+		for x := range line {
+			line[x] = bg
+		}
+		d := float64(y) + 0.5 - fx.horizon
+		if d <= 0 {
+			continue
+		}
+		// Distance relative to the bottom of the screen.
+		z := fx.bottom / d
+		if z >= cfg.Fade {
+			continue
+		}
+		v := scroll - vScale*(z-1)
+		if v < 0 || v >= float64(fx.textH) {
+			continue
+		}
+		uStep := fx.uBottom * z
+
+		// Lines are squeezed more than columns, so use a level between.
+		level := 0
+		if s := uStep * math.Sqrt(z); s >= 2 {
+			level = int(math.Log2(s))
+		}
+		if level >= len(fx.mips) {
+			level = len(fx.mips) - 1
+		}
+		tex := fx.mips[level]
+		div := float64(int(1) << uint(level))
+		row := tex.Pix[int(v/div)*tex.Stride : int(v/div)*tex.Stride+tex.Rect.Dx()]
+
+		// Texture slope of the line, centered on the text.
+		uEveryX := int(uStep / div * DecimalMul)
+		u := int((float64(fx.textW)/2 - (float64(fx.w)/2-0.5)*uStep) / div * DecimalMul)
+		uMax := fx.textW * DecimalMul >> uint(level)
+
+		// Fade towards the horizon.
+		f := 1 - (z-1)/(cfg.Fade-1)
+		mul := int(f * f * float64(cfg.Color))
+		for x := range line {
+			if u >= 0 && u < uMax {
+				if c := uint8(int(row[u>>DecimalPointLog]) * mul >> 8); c > bg {
+					line[x] = c
+				}
+			}
+			u += uEveryX
+		}
+	}
+	return fx.draw
+}
+
+// pow2 returns the smallest power of two that is at least n.
+func pow2(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
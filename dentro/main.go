@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/color"
 	_ "image/png"
 	"math"
 	"math/bits"
@@ -12,17 +13,32 @@ import (
 
 	_ "github.com/klauspost/gad/dentro/data" // Load data.
 	"github.com/klauspost/gad/dentro/screen"
+	"github.com/klauspost/gad/lut"
+	"github.com/klauspost/gad/particle"
+	"github.com/klauspost/gad/rng"
+	"github.com/klauspost/gad/sprite"
 	"github.com/klauspost/gfx"
 )
 
 // Generates binary data.
 // To install go-bindata, do: go get -u github.com/jteeuwen/go-bindata/...
 //
+//go:generate go run ../lut/cmd/lutgen/main.go -w=640 -h=360 -tunnel=16,1 -o=./data
 //go:generate go-bindata -ignore=\.go\z -pkg=data -o ./data/data.go ./data/...
 
 const (
 	renderWidth  = 640
 	renderHeight = 360
+
+	// dofAperture is the blur radius in pixels of particles infinitely far away.
+	// Set to 0 to disable depth of field.
+	dofAperture = 12
+
+	// Particles morph between shapes, one shape per loop.
+	shapeFlower = 0
+	shapeDuck   = 1
+	shapeText   = 2
+	shapeTitle  = "Go After Dark"
 )
 
 func main() {
@@ -31,14 +47,6 @@ func main() {
 	//gfx.RunWriteToDisk(fx, 11, "./saved/frame-%05d.png")
 }
 
-type coord struct{ x, y, z float32 }
-
-func (c *coord) scale(f float32) {
-	c.x *= f
-	c.y *= f
-	c.z *= f
-}
-
 var texts = [][2]string{
 	{
 		`--- >> Welcome to  Go After Dark  << ---`,
@@ -88,10 +96,12 @@ var texts = [][2]string{
 
 type fx struct {
 	draw             *image.Gray
+	rgba             *image.RGBA
+	colors           *sprite.Gradient
 	logW, logH       uint
 	lines            [][]byte
-	dots             []coord
-	mipmaps          []*image.Gray
+	morph            *particle.Morph
+	sprite           *sprite.Sprite
 	img              *image.Gray
 	tunnelTex        *image.Gray
 	lookup           [][]uint32
@@ -101,11 +111,19 @@ type fx struct {
 	lastT            float64
 }
 
-func newFx(scene, particle, light string) *fx {
+func newFx(scene, flower, light string) *fx {
 	var fx fx
 
-	// Create our draw buffer
+	// Create our draw buffer.
+	// The tunnel and text are drawn in grey and the particles in color on top.
 	fx.draw = image.NewGray(image.Rect(0, 0, renderWidth, renderHeight))
+	fx.rgba = image.NewRGBA(fx.draw.Rect)
+	// Each particle keeps its own color through all shapes.
+	fx.colors = sprite.NewGradient(
+		color.RGBA{R: 0, G: 173, B: 216, A: 255},
+		color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		color.RGBA{R: 206, G: 48, B: 98, A: 255},
+	)
 
 	// Store each line as a slice in a slice.
 	w, h := fx.draw.Rect.Dx(), fx.draw.Rect.Dy()
@@ -113,7 +131,7 @@ func newFx(scene, particle, light string) *fx {
 	for y := range fx.lines {
 		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
 	}
-	fx.dots = make([]coord, 0)
+	var duck particle.Shape
 	b, err := gfx.Load(scene)
 	if err != nil {
 		panic(err)
@@ -125,57 +143,39 @@ func newFx(scene, particle, light string) *fx {
 		if !strings.HasPrefix(t, "v ") {
 			continue
 		}
-		var c coord
-		n, err := fmt.Sscanf(t, "v %f %f %f", &c.x, &c.y, &c.z)
+		var c particle.Vec3
+		n, err := fmt.Sscanf(t, "v %f %f %f", &c.X, &c.Y, &c.Z)
 		if err != nil {
 			panic(err)
 		}
 		if n != 3 {
 			panic("not 3")
 		}
-		c.y *= -1
-		c.y += 1.8
-		c.scale(150)
-		fx.dots = append(fx.dots, c)
+		c.Y *= -1
+		c.Y += 1.8
+		duck = append(duck, c.Scale(150))
 	}
-	// Load picture
-	img, err := gfx.LoadPalPicture(particle)
+	// Load picture and calculate mipmaps.
+	fx.sprite, err = sprite.Load(flower)
 	if err != nil {
 		panic(err)
 	}
-	fx.img = gfx.ToGray(img)
-
-	// Calculate the with in log(2)
-	fx.logW = uint(bits.Len32(uint32(img.Rect.Dx()))) - 1
-	fx.logH = uint(bits.Len32(uint32(img.Rect.Dy()))) - 1
-
-	// Ensure that the width and height are actually powers of two
-	if img.Rect.Dx() != 1<<fx.logW {
-		panic("image " + particle + " width is not power of two.")
-	}
-	if img.Rect.Dy() != 1<<fx.logH {
-		panic("image " + particle + " width is not power of two.")
-	}
-	if img.Rect.Dx() != img.Rect.Dy() {
-		panic("image " + particle + " width is same as height.")
-	}
 
-	fx.mipmaps = make([]*image.Gray, fx.logW+1)
-	fx.mipmaps[fx.logW] = fx.img
-	prev := fx.img
-	for i := int(fx.logW - 1); i >= 0; i-- {
-		img := image.NewGray(image.Rect(0, 0, prev.Rect.Dx()/2, prev.Rect.Dy()/2))
-		fx.mipmaps[i] = img
-		for y := 0; y < img.Rect.Dy(); y++ {
-			src0, src1 := prev.Pix[y*2*prev.Stride:], prev.Pix[(y*2+1)*prev.Stride:]
-			dst := img.Pix[y*img.Stride : (y+1)*img.Stride]
-			for x := range dst {
-				// Average 4 pixels.
-				dst[x] = uint8((uint(src0[x*2]) + uint(src0[x*2+1]) + uint(src1[x*2]) + uint(src0[x*2+1]) + 2) >> 2)
-			}
-		}
-		prev = img
+	// The flower the particles are drawn with and the title are sampled
+	// to as many particles as the duck has vertices.
+	flowerImg, err := gfx.LoadPalPicture(flower)
+	if err != nil {
+		panic(err)
 	}
+	title := image.NewGray(image.Rect(0, 0, len(shapeTitle)*8, 16))
+	titleFx := screen.NewFx("data/dosfont.png", title)
+	titleFx.DrawText(shapeTitle, 0, 0)
+	titleFx.Render(1)
+	shapes := make([]particle.Shape, 3)
+	shapes[shapeFlower] = particle.Sample(particle.NewImage(flowerImg), len(duck), 1).Scale(2.5)
+	shapes[shapeDuck] = duck
+	shapes[shapeText] = particle.Sample(particle.NewImage(title), len(duck), 2).Scale(3)
+	fx.morph = particle.NewMorph(3, 0.5, 0.5, 0.25, shapes...)
 	fx.img, err = gfx.LoadGreyPicture(light)
 	if err != nil {
 		panic(err)
@@ -184,24 +184,10 @@ func newFx(scene, particle, light string) *fx {
 	fx.logW = uint(bits.Len32(uint32(fx.img.Rect.Dx()))) - 1
 	fx.logH = uint(bits.Len32(uint32(fx.img.Rect.Dy()))) - 1
 
-	// Store each line as a slice in a slice.
-	fx.lookup = make([][]uint32, h)
-	for y := range fx.lines {
-		lu := make([]uint32, w)
-		for x := range lu {
-			// Number of bits to represent width/height of texture.
-			const prec = 1 << 16
-			var angle, distance int
-			// Number of distance repetitions.
-			const ratio = 16
-			distance = int(ratio*prec/
-				math.Sqrt(float64(x-w/2)*float64(x-w/2)+float64(y-h/2)*float64(y-h/2))) % prec
-			angle = (int)(prec*math.Atan2(float64(y-h/2), float64(x-w/2))/math.Pi) % prec
-			// Store distance as lower 16 bits, angle as upper
-			lu[x] = uint32(distance) | uint32(angle<<16)
-		}
-		fx.lookup[y] = lu
-	}
+	// Distance and angle for each pixel, with 16 distance repetitions.
+	// Loaded if it has been generated, since it is slow to calculate.
+	tunnel := lut.Tunnel(w, h, 16, 1)
+	fx.lookup = tunnel.Lines(tunnel.Load())
 	fx.tunnelTex, err = gfx.LoadGreyPicture("data/wildtextures-african-grey.png")
 	if err != nil {
 		panic(err)
@@ -246,17 +232,17 @@ func (fx *fx) Render(t float64) image.Image {
 	shiftXL := int(2 * -t * 256 * float64(xMaskL+1))
 	shiftYL := int(8 * -t * 256 * float64(yMaskL+1))
 
-	seed := uint32(t * math.MaxUint32)
-	rng15i := func() int {
-		seed = 214013*seed + 2531011
-		return int(seed>>16) & 63
+	// Grain on the tunnel texture, changing every frame.
+	grain := rng.NewXorshift(uint32(t * math.MaxUint32))
+	rng6i := func() int {
+		return int(grain.Next()>>16) & 63
 	}
 	for y, line := range fx.lines {
 		lu := fx.lookup[y]
 		for x := range line {
 			v := int(lu[x])
-			srcX := ((rng15i() + shiftX + (v & 0xffff)) >> fpX) & xMask
-			srcY := ((rng15i() + shiftY + (v >> 16)) >> fpY) & yMask
+			srcX := ((rng6i() + shiftX + (v & 0xffff)) >> fpX) & xMask
+			srcY := ((rng6i() + shiftY + (v >> 16)) >> fpY) & yMask
 			srcXL := ((shiftXL + (v & 0xffff)) >> fpXL) & xMaskL
 			srcYL := ((shiftYL + (v >> 16)) >> fpYL) & yMaskL
 			line[x] = uint8((uint32(fx.tunnelTex.Pix[srcX+(srcY<<tunLogW)]) *
@@ -268,43 +254,73 @@ func (fx *fx) Render(t float64) image.Image {
 		halfHeight = renderHeight * 0.5
 	)
 
+	if fx.lastT > t {
+		fx.atText++
+		fx.text.ClearScreen()
+		txt := texts[fx.atText%len(texts)][:]
+		fx.text.DrawText(strings.Join(txt, "\n"), 0, 0)
+	}
+	fx.lastT = t
+
 	// Offset z on all points over time, effectively moving the "camera" forward.
 	zoff := 400 + float32(math.Sin(t*math.Pi*4)*300)
+	// Depth of field, focused on the center of the model.
+	dof := particle.Projection{Focus: zoff, Aperture: dofAperture}
 	// Create rotation matrix
 	rot := rotateFn(-math.Pi/2+0.075*math.Cos(t*math.Pi*16), 0.3+(1-t)*math.Pi*2, 0)
 	zMul := float32(t * 0.5 * 200)
 	if t > 0.5 {
 		zMul -= float32((t - 0.5) * 200 * 255 * 16)
 	}
-	for _, d := range fx.dots {
-		rot(&d)
-		z := d.z + zoff
+	fx.text.Render(math.Min(1, t*2))
+	greyToRGBA(fx.rgba, fx.draw)
+
+	// Only the duck is rotated, the flat shapes face the camera.
+	from, to, f := fx.morph.Segment(float32(fx.atText) + float32(t))
+	shapeA, shapeB := fx.morph.Shapes[from], fx.morph.Shapes[to]
+	for i := range shapeA {
+		a, b := shapeA[i], shapeB[i]
+		if from == shapeDuck {
+			rot(&a)
+		}
+		if to == shapeDuck {
+			rot(&b)
+		}
+		d := a.Add(b.Sub(a).Scale(fx.morph.Progress(i, f)))
+		z := d.Z + zoff
 		if z <= 0 {
 			continue
 		}
 		invZ := 0.8 / z
-		x := 200 * d.x * invZ
-		y := 200 * d.y * invZ
+		x := 200 * d.X * invZ
+		y := 200 * d.Y * invZ
 		x += halfWidth
 		y += halfHeight
 
 		zsize := 40 * 255 * 16 * invZ
-		fx.drawParticleMip(int32(x*256), int32(y*256), int32(zsize))
+		blur := dof.Blur(z)
+		col := fx.colors.At(float32(i) / float32(len(shapeA)))
+		//col := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+		fx.sprite.DrawBlurredRGBA(fx.rgba, int32(x*256), int32(y*256), int32(zsize), int32(blur*256), 0, sprite.Mip, sprite.Add, col)
 	}
-	if fx.lastT > t {
-		fx.atText++
-		fx.text.ClearScreen()
-		txt := texts[fx.atText%len(texts)][:]
-		fx.text.DrawText(strings.Join(txt, "\n"), 0, 0)
+	return fx.rgba
+}
+
+// greyToRGBA copies the grey image src to dst.
+func greyToRGBA(dst *image.RGBA, src *image.Gray) {
+	w := src.Rect.Dx()
+	for y := 0; y < src.Rect.Dy(); y++ {
+		s := src.Pix[y*src.Stride : y*src.Stride+w]
+		d := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x, v := range s {
+			d[x*4], d[x*4+1], d[x*4+2], d[x*4+3] = v, v, v, 255
+		}
 	}
-	fx.lastT = t
-	fx.text.Render(math.Min(1, t*2))
-	return fx.draw
 }
 
 // rotateFn returns a function that rotates around (0,0,0).
 // Supply angles in radians.
-func rotateFn(xAn, yAn, zAn float64) func(c *coord) {
+func rotateFn(xAn, yAn, zAn float64) func(c *particle.Vec3) {
 	var (
 		s1 = float32(math.Sin(zAn))
 		s2 = float32(math.Sin(xAn))
@@ -323,166 +339,9 @@ func rotateFn(xAn, yAn, zAn float64) func(c *coord) {
 		seven = c2 * c3
 		eight = s1*s3 + c1*c3*s2
 	)
-	return func(c *coord) {
-		c.x, c.y, c.z = c.x*zero+c.y*one+c.z*two, c.x*three+c.y*four+c.z*five, c.x*six+c.y*seven+c.z*eight
-	}
-}
-
-// drawParticle will draw a particle centered at x,y with radius r.
-// Input is assumed to be 24.8
-func (fx *fx) drawParticleMip(x, y, r int32) {
-	m := fx.calcMapping(x, y, r, -1)
-	if m.mipSize != 0 && m.mip == nil {
-		fx.drawPoint(&m)
-		return
-	}
-	v := m.v0
-	for y := m.startY; y < m.endY; y++ {
-		dst := fx.draw.Pix[int(y)*fx.draw.Stride:]
-		mipLine := m.mip.Pix[(v>>16)*uint32(m.mip.Stride):]
-		u := m.u0
-		for x := m.startX; x < m.endX; x++ {
-			xPos := u >> 16
-			dst[x] = clamp8uint32(uint32(mipLine[xPos]) + uint32(dst[x]))
-			u += m.uStep
-		}
-		v += m.vStep
-	}
-}
-
-// drawPoint will draw a single point between the pixel.
-func (fx *fx) drawPoint(m *mapping) {
-	dst := fx.draw.Pix[m.startX+m.startY*int32(fx.draw.Stride):]
-	dst[0] = clamp8uint32((m.u0*m.v0)>>8 + uint32(dst[0]))
-	if m.endX < renderWidth {
-		dst[1] = clamp8uint32((m.u1*m.v0)>>8 + uint32(dst[1]))
-	}
-	if m.endY >= renderHeight {
-		return
-	}
-	dst = dst[fx.draw.Stride:]
-	dst[0] = clamp8uint32((m.u0*m.v1)>>8 + uint32(dst[0]))
-	if m.endX < renderWidth {
-		dst[1] = clamp8uint32((m.u1*m.v1)>>8 + uint32(dst[1]))
-	}
-}
-
-func clamp8uint32(v uint32) uint8 {
-	if v >= 255 {
-		return 255
-	}
-	return uint8(v)
-}
-
-type mapping struct {
-	startX, endX, startY, endY int32
-	u0, u1, v0, v1             uint32
-	vStep, uStep               uint32
-	// Texture coordinates as 16.16 fixed point.
-	mipSize uint32
-	mip     *image.Gray
-}
-
-// calcMapping will return a mapping for a sprite with radius r placed at (x,y)
-// at the specified mip level.
-func (fx *fx) calcMapping(x, y, r, mip int32) mapping {
-	var m mapping
-	// Quick discard
-	if x+r < 0 || x-r > (renderWidth*256) || y+r < 0 || y-r > (renderHeight*256) {
-		return m
-	}
-	// For very small radius we simply draw a point
-	if r <= 128 {
-		m.startX, m.endX = (x-r)>>8, (x-r)>>8+1
-		m.startY, m.endY = (y-r)>>8, (y-r)>>8+1
-		if m.startX >= renderWidth || m.startX < 0 || m.startY >= renderHeight || m.startY < 0 {
-			return m
-		}
-		m.u1 = uint32(x-r) & 0xff
-		m.u0 = 256 - m.u1
-		m.v1 = uint32(y-r) & 0xff
-		m.v0 = 256 - m.v1
-
-		// Radius times 1x1 pixel value.
-		rmip := (r * int32(fx.mipmaps[0].Pix[0])) >> 5
-		m.u0 = (m.u0 * uint32(rmip)) >> 8
-		m.u1 = (m.u1 * uint32(rmip)) >> 8
-		m.v0 = (m.v0 * uint32(rmip)) >> 8
-		m.v1 = (m.v1 * uint32(rmip)) >> 8
-		m.mipSize = 1
-		// leave mip nil
-		fx.drawPoint(&m)
-		return m
-	}
-	mipLevel := mip
-	if mip < 0 {
-		mipLevel = int32(bits.Len32(uint32(r>>6))) - 1
-		if int(mipLevel) >= len(fx.mipmaps) {
-			mipLevel = int32(len(fx.mipmaps)) - 1
-		} else if mipLevel < 1 {
-			mipLevel = 1
-		}
-	}
-	m.mip = fx.mipmaps[mipLevel]
-	m.mipSize = uint32(1<<16) << uint(mipLevel)
-
-	// output pixels per texture pixels * 256
-	textureScale := float64(m.mip.Rect.Dx()) / (float64(r) / (128 * 256))
-
-	// Screen space start, rounded down
-	m.startX, m.startY = (x-r)>>8, (y-r)>>8
-	// Screen space, rounded up.
-	m.endX, m.endY = (x+r+255)>>8, (y+r+255)>>8
-
-	// Calculate rounded difference and convert to texture space.
-	m.u0, m.v0 = 255-uint32((x-r)-(m.startX<<8)), 255-uint32((y-r)-(m.startY<<8))
-	m.u0, m.v0 = uint32(float64(m.u0)*textureScale), uint32(float64(m.v0)*textureScale)
-
-	// Calculate rounded difference and convert to texture space.
-	m.u1, m.v1 = 255-uint32((m.endX<<8)-(x+r)), 255-uint32((m.endY<<8)-(y+r))
-	m.u1, m.v1 = m.mipSize-uint32(float64(m.u1)*textureScale), m.mipSize-uint32(float64(m.v1)*textureScale)
-
-	// Calculate step size per screen space pixel.
-	m.uStep = uint32(float64(m.u1-m.u0) / float64(m.endX-m.startX))
-	m.vStep = uint32(float64(m.v1-m.v0) / float64(m.endY-m.startY))
-
-	if false && (m.uStep == 0 || m.vStep == 0 || m.u0 >= m.u1 || m.v0 >= m.v1) {
-		fmt.Printf("r:%d, m:%+v v0:%v, v1: %v scale:%v (%d, %d), x,y (%v, %v)\n",
-			r, m, 256-uint32((y-r)-(m.startY<<8)), 256-uint32((m.endY<<8)-(y+r)), textureScale/256, m.endX-m.startX, m.mip.Rect.Dx(),
-			float64(x)/256, float64(y)/256)
-		// Sanity check
-		m.mip = nil
-		return m
-	}
-
-	// Clip left, top, right, bottom.
-	if m.startX < 0 {
-		m.u0 += m.uStep * uint32(-m.startX)
-		m.startX = 0
-	}
-	if m.startY < 0 {
-		m.v0 += m.vStep * uint32(-m.startY)
-		m.startY = 0
-	}
-	if m.endX > renderWidth {
-		// Not needed for most
-		m.v1 -= uint32(m.endX-renderWidth) * m.uStep
-		m.endX = renderWidth
-	}
-	if m.endY > renderHeight {
-		// Not needed for most
-		m.v1 -= uint32(m.endY-renderHeight) * m.vStep
-		m.endY = renderHeight
-	}
-
-	if false {
-		// Final sanity to make sure we don't go over due to rounding.
-		for ; m.u0+m.uStep*uint32(m.endX-m.startX) >= m.mipSize; m.endX-- {
-		}
-		for ; m.v0+m.vStep*uint32(m.endY-m.startY) >= m.mipSize; m.endY-- {
-		}
+	return func(c *particle.Vec3) {
+		c.X, c.Y, c.Z = c.X*zero+c.Y*one+c.Z*two, c.X*three+c.Y*four+c.Z*five, c.X*six+c.Y*seven+c.Z*eight
 	}
-	return m
 }
 
 func init() {
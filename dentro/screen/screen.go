@@ -1,6 +1,7 @@
 package screen
 
 import (
+	"errors"
 	"image"
 
 	"github.com/klauspost/gfx"
@@ -9,7 +10,7 @@ import (
 type Fx struct {
 	// main and mipmaps
 	screen           [][]byte
-	font             [128][16]byte
+	font             *Font
 	fontLU           [256][8]byte
 	draw             *image.Gray
 	lines            [][]byte
@@ -21,14 +22,40 @@ func NewFx(file string, dst *image.Gray) *Fx {
 	var fx Fx
 	fx.draw = dst
 
+	font, err := LoadFont(file)
+	if err != nil {
+		panic(err)
+	}
+	fx.font = font
+	fx.screen = make([][]byte, dst.Rect.Dy()/16)
+	fx.ClearScreen()
+	fx.SetColor(224, 0)
+	// Store each line as a slice in a slice.
+	w, h := fx.draw.Rect.Dx(), fx.draw.Rect.Dy()
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+
+	return &fx
+}
+
+// Font is an 8x16 font with 128 characters.
+// Each character is 16 lines, with the leftmost pixel in the top bit.
+type Font [128][16]byte
+
+// LoadFont loads a 512x32 font picture with 64 characters on each line.
+// Pixels that are not color 0 are set.
+func LoadFont(file string) (*Font, error) {
 	// Load picture
 	img, err := gfx.LoadPalPicture(file)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	if img.Rect.Dx() != 512 || img.Rect.Dy() != 32 {
-		panic("image " + file + " size is not 512x32")
+		return nil, errors.New("image " + file + " size is not 512x32")
 	}
+	var font Font
 	for i := 0; i < 64; i++ {
 		for y := 0; y < 16; y++ {
 			var v, v2 uint8
@@ -42,21 +69,16 @@ func NewFx(file string, dst *image.Gray) *Fx {
 					v2 |= 1
 				}
 			}
-			fx.font[i][y] = v
-			fx.font[i+64][y] = v2
+			font[i][y] = v
+			font[i+64][y] = v2
 		}
 	}
-	fx.screen = make([][]byte, dst.Rect.Dy()/16)
-	fx.ClearScreen()
-	fx.SetColor(224, 0)
-	// Store each line as a slice in a slice.
-	w, h := fx.draw.Rect.Dx(), fx.draw.Rect.Dy()
-	fx.lines = make([][]byte, h)
-	for y := range fx.lines {
-		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
-	}
+	return &font, nil
+}
 
-	return &fx
+// Font returns the font of the screen.
+func (fx *Fx) Font() *Font {
+	return fx.font
 }
 
 func (fx *Fx) SetDraw(img *image.Gray) {
@@ -6,6 +6,8 @@ import (
 	"math/bits"
 
 	_ "github.com/klauspost/gad/ep02/data" // Load data.
+	"github.com/klauspost/gad/fixed"
+	"github.com/klauspost/gad/texture"
 	"github.com/klauspost/gfx"
 )
 
@@ -21,6 +23,8 @@ const (
 
 func main() {
 	fx := newFx("data/ballet-egon-256.png")
+	// Generated texture, no data needed:
+	//fx := newFxImage(xorTexture())
 	gfx.Run(func() { gfx.RunTimed(fx) })
 }
 
@@ -32,13 +36,25 @@ type fx struct {
 }
 
 func newFx(file string) *fx {
-	var fx fx
-
 	// Load picture
 	img, err := gfx.LoadPalPicture(file)
 	if err != nil {
 		panic(err)
 	}
+	return newFxImage(img)
+}
+
+// xorTexture returns a generated XOR texture with a rainbow palette.
+func xorTexture() *image.Paletted {
+	img, err := texture.Paletted(256, 256, texture.XOR, texture.RainbowPalette)
+	if err != nil {
+		panic(err)
+	}
+	return img
+}
+
+func newFxImage(img *image.Paletted) *fx {
+	var fx fx
 	fx.img = img
 
 	// Calculate the with in log(2)
@@ -47,10 +63,10 @@ func newFx(file string) *fx {
 
 	// Ensure that the width and height are actually powers of two
 	if img.Rect.Dx() != 1<<fx.logW {
-		panic("image " + file + " width is not power of two.")
+		panic("image width is not power of two.")
 	}
 	if img.Rect.Dy() != 1<<fx.logH {
-		panic("image " + file + " height is not power of two.")
+		panic("image height is not power of two.")
 	}
 
 	// Create our draw buffer
@@ -66,12 +82,8 @@ func newFx(file string) *fx {
 
 // Render the effect at time t.
 func (fx *fx) Render(t float64) image.Image {
-	const (
-		DecimalPointLog = 16
-		DecimalMul      = 1 << DecimalPointLog
-	)
 	// tt is our reverse zoom as 16.16 fixed point
-	tt := int(t * t * 12 * DecimalMul)
+	tt := fixed.F16_16(t * t * 12)
 
 	xMask := (1 << fx.logW) - 1
 	yMask := (1 << fx.logH) - 1
@@ -79,20 +91,20 @@ func (fx *fx) Render(t float64) image.Image {
 	// Center of zoom (screen space)
 	centerX, centerY := renderWidth/2, renderHeight/2
 	// Store the reverse transformation for the center of screen.
-	x0 := -tt * centerX
-	y0 := -tt * centerY
+	x0 := -tt.MulInt(centerX)
+	y0 := -tt.MulInt(centerY)
 
 	// Center on texture (texture space)
 	texCenterX, texCenterY := 173, 106
-	x0 += texCenterX * DecimalMul
-	y0 += texCenterY * DecimalMul
+	x0 += fixed.I16_16(texCenterX)
+	y0 += fixed.I16_16(texCenterY)
 
 	for y, line := range fx.lines {
-		srcY := ((y0 + y*tt) >> DecimalPointLog) & yMask
+		srcY := (y0 + tt.MulInt(y)).Int() & yMask
 		// Pre-shift, so srcY is offset for x=0 at our line.
 		srcY <<= fx.logW
 		for x := range line {
-			srcX := ((x0 + x*tt) >> DecimalPointLog) & xMask
+			srcX := (x0 + tt.MulInt(x)).Int() & xMask
 			line[x] = fx.img.Pix[srcX+srcY]
 		}
 	}
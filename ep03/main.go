@@ -7,6 +7,7 @@ import (
 	"math/bits"
 
 	_ "github.com/klauspost/gad/ep03/data" // Load data.
+	"github.com/klauspost/gad/texture"
 	"github.com/klauspost/gfx"
 )
 
@@ -22,6 +23,8 @@ const (
 
 func main() {
 	fx := newRotoZoom("data/ashleymcnamara-pride_256.png")
+	// Generated texture, no data needed:
+	//fx := newRotoZoomImage(truchetTexture())
 	gfx.Run(func() { gfx.RunTimed(fx) })
 }
 
@@ -33,13 +36,25 @@ type RotoZoomer struct {
 }
 
 func newRotoZoom(file string) *RotoZoomer {
-	var rz RotoZoomer
-
 	// Load picture
 	img, err := gfx.LoadPalPicture(file)
 	if err != nil {
 		panic(err)
 	}
+	return newRotoZoomImage(img)
+}
+
+// truchetTexture returns generated Truchet tiles colored like wood.
+func truchetTexture() *image.Paletted {
+	img, err := texture.Paletted(256, 256, texture.Invert(texture.Truchet(1, 8)), texture.WoodPalette)
+	if err != nil {
+		panic(err)
+	}
+	return img
+}
+
+func newRotoZoomImage(img *image.Paletted) *RotoZoomer {
+	var rz RotoZoomer
 	rz.img = img
 
 	// Calculate the with in log(2)
@@ -48,10 +63,10 @@ func newRotoZoom(file string) *RotoZoomer {
 
 	// Ensure that the width and height are actually powers of two
 	if img.Rect.Dx() != 1<<rz.logW {
-		panic("image " + file + " width is not power of two.")
+		panic("image width is not power of two.")
 	}
 	if img.Rect.Dy() != 1<<rz.logH {
-		panic("image " + file + " width is not power of two.")
+		panic("image height is not power of two.")
 	}
 
 	// Create our draw buffer
@@ -7,6 +7,8 @@ import (
 	"math/bits"
 
 	_ "github.com/klauspost/gad/ep04/data" // Load data.
+	"github.com/klauspost/gad/lut"
+	"github.com/klauspost/gad/texture"
 	"github.com/klauspost/gfx"
 )
 
@@ -18,10 +20,13 @@ const (
 // Generates binary data.
 // To install go-bindata, do: go get -u github.com/jteeuwen/go-bindata/...
 //
+//go:generate go run ../lut/cmd/lutgen/main.go -w=640 -h=360 -tunnel=8,0.5 -o=./data
 //go:generate go-bindata -ignore=\.go\z -pkg=data -o ./data/data.go ./data/...
 
 func main() {
 	fx := newTunnel("data/wildtextures-african-inspir.png")
+	// Generated texture, no data needed:
+	//fx := newTunnelImage(marbleTexture())
 	//gfx.RunWriteToDisk(fx, 1, "./saved/tunnel-%05d.png")
 	gfx.Run(func() { gfx.RunTimed(fx) })
 }
@@ -35,13 +40,25 @@ type tunnel struct {
 }
 
 func newTunnel(file string) *tunnel {
-	var fx tunnel
-
 	// Load picture
 	img, err := gfx.LoadPalPicture(file)
 	if err != nil {
 		panic(err)
 	}
+	return newTunnelImage(img)
+}
+
+// marbleTexture returns a generated marble texture.
+func marbleTexture() *image.Paletted {
+	img, err := texture.Paletted(256, 256, texture.Marble(1, 3, 4), texture.MarblePalette)
+	if err != nil {
+		panic(err)
+	}
+	return img
+}
+
+func newTunnelImage(img *image.Paletted) *tunnel {
+	var fx tunnel
 	fx.img = img
 
 	// Calculate the with in log(2)
@@ -50,10 +67,10 @@ func newTunnel(file string) *tunnel {
 
 	// Ensure that the width and height are actually powers of two
 	if img.Rect.Dx() != 1<<fx.logW {
-		panic("image " + file + " width is not power of two.")
+		panic("image width is not power of two.")
 	}
 	if img.Rect.Dy() != 1<<fx.logH {
-		panic("image " + file + " width is not power of two.")
+		panic("image height is not power of two.")
 	}
 
 	// Create our draw buffer
@@ -62,27 +79,14 @@ func newTunnel(file string) *tunnel {
 	// Store each line as a slice in a slice.
 	w, h := fx.draw.Rect.Dx(), fx.draw.Rect.Dy()
 	fx.lines = make([][]byte, h)
-
-	fx.lookup = make([][]uint32, h)
 	for y := range fx.lines {
 		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+fx.draw.Rect.Dx()]
-		lu := make([]uint32, w)
-		for x := range lu {
-			// Number of distance repetitions.
-			const ratio = 8
-			// texture coordinates for screen space coordinates (x,y)
-			centerX, centerY := float64(x-w/2), float64(y-h/2)
-
-			// distance is u coordinate, 0 -> coordPrec
-			distance := int(ratio*coordPrec/math.Sqrt(centerX*centerX+centerY*centerY)) % coordPrec
-			// angle is v coordinate 0 -> coordPrec
-			angle := (int)(0.5*coordPrec*math.Atan2(centerY, centerX)/math.Pi) % coordPrec
-
-			// Store distance as lower 16 bits, angle as upper
-			lu[x] = uint32(distance) | uint32(angle<<16)
-		}
-		fx.lookup[y] = lu
 	}
+
+	// Distance and angle for each pixel, with 8 distance repetitions.
+	// Loaded if it has been generated, since it is slow to calculate.
+	tunnel := lut.Tunnel(w, h, 8, 0.5)
+	fx.lookup = tunnel.Lines(tunnel.Load())
 	return &fx
 }
 
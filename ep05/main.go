@@ -5,10 +5,9 @@ import (
 	"image/color"
 	_ "image/png"
 	"math"
-	"math/rand"
-	"time"
 
 	_ "github.com/klauspost/gad/ep05/data" // Load data.
+	"github.com/klauspost/gad/rng"
 	"github.com/klauspost/gfx"
 )
 
@@ -24,6 +23,8 @@ const (
 
 func main() {
 	gfx.InitShadedPalette(192, color.RGBA{R: 251, G: 246, B: 158})
+	// Use a different random seed for every run:
+	//rng.SetSeed(time.Now().UnixNano())
 	fx := newFx()
 	//gfx.RunWriteToDisk(fx, 1, "./saved/dots-%05d.png")
 	gfx.Run(func() { gfx.RunTimed(fx) })
@@ -54,14 +55,14 @@ func newFx() *fx {
 	// Generate some dots in a cylinder along positive z axis.
 	const dots = 50000
 	fx.dots = make([]coord, dots)
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd := rng.New(0xd075)
 	for i := range fx.dots {
 		// Random angle
-		angle := rng.Float64() * math.Pi * 2
+		angle := rnd.Float64() * math.Pi * 2
 		// Random radius.
-		r := rng.Float64() * renderWidth * 3
+		r := rnd.Float64() * renderWidth * 3
 		// Random depth
-		z := rng.Float32() * 50
+		z := rnd.Float32() * 50
 		x := math.Sin(angle)
 		y := math.Cos(angle)
 
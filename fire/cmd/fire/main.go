@@ -0,0 +1,58 @@
+package main
+
+import (
+	"image"
+	_ "image/png"
+	"time"
+
+	_ "github.com/klauspost/gad/dentro/data" // Load font.
+	"github.com/klauspost/gad/dentro/screen"
+	"github.com/klauspost/gad/fire"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+	text         = "Go After Dark"
+	// Text is scaled up this much.
+	textScale = 4
+	// Length of the loop in seconds.
+	loop = 10
+)
+
+func main() {
+	fire.InitGreyPalette(fire.Palette)
+	draw := image.NewGray(image.Rect(0, 0, renderWidth, renderHeight))
+	c := fire.Default
+	c.Wind = 0.25
+	c.Clock.Duration = loop
+	fx := fire.NewGray(draw, c)
+
+	// Burn text, without fire at the bottom.
+	mask := textMask(text, textScale)
+	fx.SetMask(mask, (renderWidth-mask.Rect.Dx())/2, (renderHeight-mask.Rect.Dy())/2)
+	//fx.Heat = 0
+
+	gfx.Run(func() { gfx.RunTimedDur(fx, loop*time.Second) })
+}
+
+// textMask returns the text drawn with the screen font, scaled up.
+func textMask(s string, scale int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(s)*8, 16))
+	txt := screen.NewFx("data/dosfont.png", img)
+	txt.SetColor(255, 0)
+	txt.DrawText(s, 0, 0)
+	txt.Render(1)
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dst := image.NewGray(image.Rect(0, 0, w*scale, h*scale))
+	for y := 0; y < h*scale; y++ {
+		line := dst.Pix[y*dst.Stride : y*dst.Stride+w*scale]
+		src := img.Pix[(y/scale)*img.Stride:]
+		for x := range line {
+			line[x] = src[x/scale]
+		}
+	}
+	return dst
+}
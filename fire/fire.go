@@ -0,0 +1,197 @@
+// Package fire renders the Doom style fire effect.
+//
+// Heat is stored as a value 0 -> 255 for each pixel.
+// Every step heat moves one line up, loses a random amount of heat and
+// is moved a random amount sideways, plus the wind.
+// The heat is drawn directly to an *image.Gray or *image.Paletted,
+// so a fire palette turns it into flames.
+package fire
+
+import (
+	"image"
+	"math"
+
+	"github.com/klauspost/gad/rng"
+	"github.com/klauspost/gad/tick"
+)
+
+// Config contains the fire settings.
+// They can be changed between frames.
+type Config struct {
+	// Decay is the maximum heat lost per line. The loss is random, 0 -> Decay.
+	Decay int
+
+	// Spread is the maximum random sideways movement per line in pixels.
+	Spread int
+
+	// Wind is the number of pixels the flames move sideways per line.
+	// Negative values move left.
+	Wind float64
+
+	// Heat of the bottom line. If 0, the bottom line cools
+	// down and only the mask adds heat.
+	Heat uint8
+
+	// Clock decides the number of steps for each frame.
+	Clock tick.Clock
+}
+
+// Default is a fire filling about a quarter of a 360 line screen.
+// Set Clock.Duration to step it at its rate.
+var Default = Config{Decay: 6, Spread: 1, Heat: 255, Clock: tick.Clock{Rate: 60}}
+
+// Fx is a fire effect.
+type Fx struct {
+	Config
+
+	img    image.Image
+	lines  [][]byte
+	rnd    rng.Xorshift
+	mask   *image.Gray
+	maskAt image.Point
+}
+
+// NewGray returns a fire burning in dst.
+// Use InitGreyPalette to show it with the fire palette.
+func NewGray(dst *image.Gray, c Config) *Fx {
+	return newFx(dst, dst.Pix, dst.Stride, dst.Rect, c)
+}
+
+// NewPaletted returns a fire burning in dst.
+// If dst has no palette, Palette is used.
+func NewPaletted(dst *image.Paletted, c Config) *Fx {
+	if len(dst.Palette) == 0 {
+		dst.Palette = Palette
+	}
+	return newFx(dst, dst.Pix, dst.Stride, dst.Rect, c)
+}
+
+func newFx(img image.Image, pix []byte, stride int, r image.Rectangle, c Config) *Fx {
+	fx := Fx{Config: c, img: img, rnd: rng.NewXorshift(0xf1e)}
+
+	// Store each line as a slice in a slice.
+	w, h := r.Dx(), r.Dy()
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = pix[y*stride : y*stride+w]
+	}
+	return &fx
+}
+
+// SetMask sets a mask that is ignited on every step, so a logo or text keeps burning.
+// Each pixel of the mask sets the heat to at least the mask value.
+// The top left of the mask is placed at (x, y). Use nil to remove the mask.
+func (fx *Fx) SetMask(m *image.Gray, x, y int) {
+	fx.mask = m
+	fx.maskAt = image.Pt(x, y)
+}
+
+// Ignite sets the heat to at least the value of the mask once.
+// The top left of the mask is placed at (x, y).
+func (fx *Fx) Ignite(m *image.Gray, x, y int) {
+	w, h := m.Rect.Dx(), m.Rect.Dy()
+	for my := 0; my < h; my++ {
+		dy := y + my
+		if dy < 0 || dy >= len(fx.lines) {
+			continue
+		}
+		src := m.Pix[my*m.Stride : my*m.Stride+w]
+		dst := fx.lines[dy]
+		for mx, v := range src {
+			dx := x + mx
+			if dx < 0 || dx >= len(dst) {
+				continue
+			}
+			if v > dst[dx] {
+				dst[dx] = v
+			}
+		}
+	}
+}
+
+// Clear removes all heat.
+func (fx *Fx) Clear() {
+	for _, line := range fx.lines {
+		for x := range line {
+			line[x] = 0
+		}
+	}
+}
+
+// Step moves the fire one line up.
+// Nothing is done if the image is empty.
+func (fx *Fx) Step() {
+	if len(fx.lines) == 0 || len(fx.lines[0]) == 0 {
+		return
+	}
+	bottom := fx.lines[len(fx.lines)-1]
+	decay := uint32(fx.Decay + 1)
+	if fx.Heat > 0 {
+		for x := range bottom {
+			bottom[x] = fx.Heat
+		}
+	} else {
+		// Let the bottom line cool, so the fire goes out.
+		for x, v := range bottom {
+			heat := int(v) - int(fx.rnd.Next()%decay)
+			if heat < 0 {
+				heat = 0
+			}
+			bottom[x] = uint8(heat)
+		}
+	}
+	if fx.mask != nil {
+		fx.Ignite(fx.mask, fx.maskAt.X, fx.maskAt.Y)
+	}
+
+	windInt := math.Floor(fx.Wind)
+	// Fraction of the wind, 0 -> 65535.
+	windFrac := uint32((fx.Wind - windInt) * 65536)
+	spread := uint32(fx.Spread*2 + 1)
+	w := len(bottom)
+
+	// Like Doom, heat is moved in place, column by column going down.
+	// Moved heat can be moved again in the same step when the next
+	// column is processed, which gives the flames their shape.
+	off := int(windInt) - fx.Spread
+	// Keep offset positive, so wrapping is a single compare.
+	off %= w
+	if off < 0 {
+		off += w
+	}
+	lines := fx.lines
+	for x := 0; x < w; x++ {
+		for y := 1; y < len(lines); y++ {
+			v := lines[y][x]
+			if v == 0 {
+				lines[y-1][x] = 0
+				continue
+			}
+			r := fx.rnd.Next()
+			dx := x + off + int((r>>8&0xff)%spread)
+			// Add the fraction of the wind randomly.
+			if r>>16 < windFrac {
+				dx++
+			}
+			for dx >= w {
+				dx -= w
+			}
+			heat := int(v) - int(r%decay)
+			if heat < 0 {
+				heat = 0
+			}
+			lines[y-1][dx] = uint8(heat)
+		}
+	}
+}
+
+// Render the effect at time t.
+// Steps are made to keep up with the rate.
+// When the effect loops, the fire keeps burning.
+func (fx *Fx) Render(t float64) image.Image {
+	n, _ := fx.Clock.Advance(t)
+	for i := 0; i < n; i++ {
+		fx.Step()
+	}
+	return fx.img
+}
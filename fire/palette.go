@@ -0,0 +1,33 @@
+package fire
+
+import (
+	"image/color"
+
+	"github.com/klauspost/gad/texture"
+	"github.com/klauspost/gfx"
+)
+
+// Palette goes from black through red and yellow to white,
+// like the 37 colors of the Doom fire stretched to 256.
+var Palette = texture.NewPalette(false,
+	color.RGBA{R: 7, G: 7, B: 7, A: 255},
+	color.RGBA{R: 71, G: 15, B: 7, A: 255},
+	color.RGBA{R: 143, G: 39, B: 7, A: 255},
+	color.RGBA{R: 207, G: 71, B: 7, A: 255},
+	color.RGBA{R: 223, G: 111, B: 15, A: 255},
+	color.RGBA{R: 215, G: 151, B: 31, A: 255},
+	color.RGBA{R: 207, G: 191, B: 47, A: 255},
+	color.RGBA{R: 239, G: 239, B: 199, A: 255},
+	color.RGBA{R: 255, G: 255, B: 255, A: 255},
+)
+
+// InitGreyPalette sets the palette used by gfx to show *image.Gray,
+// so the heat of fires drawn with NewGray is shown with p.
+func InitGreyPalette(p color.Palette) {
+	var palette [256]uint32
+	for i := range palette[:] {
+		c := color.RGBAModel.Convert(p[i*len(p)/256]).(color.RGBA)
+		palette[i] = uint32(c.R) | (uint32(c.G) << 8) | (uint32(c.B) << 16)
+	}
+	gfx.InitGreyPalette(palette)
+}
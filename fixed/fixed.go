@@ -0,0 +1,188 @@
+// Package fixed contains fixed point types and table driven math for inner loops.
+//
+// Types are named like golang.org/x/image/math/fixed:
+// Int16_16 has 16 integer and 16 fraction bits,
+// Int24_8 has 24 integer and 8 fraction bits and
+// Int8_8 has 8 integer and 8 fraction bits.
+// All are signed.
+//
+// Plain arithmetic operators work for addition and subtraction.
+// Use MulInt to multiply with an integer and Mul and Div when both
+// values are fixed point.
+// Operations starting with Sat clamp the result instead of overflowing.
+package fixed
+
+import (
+	"fmt"
+	"math"
+)
+
+// Int16_16 is a 16.16 fixed point value.
+type Int16_16 int32
+
+// Int24_8 is a 24.8 fixed point value.
+type Int24_8 int32
+
+// Int8_8 is an 8.8 fixed point value.
+type Int8_8 int16
+
+// I16_16 returns i as 16.16.
+func I16_16(i int) Int16_16 { return Int16_16(i << 16) }
+
+// F16_16 returns f as 16.16, rounded to nearest.
+func F16_16(f float64) Int16_16 { return Int16_16(math.Floor(f*(1<<16) + 0.5)) }
+
+// I24_8 returns i as 24.8.
+func I24_8(i int) Int24_8 { return Int24_8(i << 8) }
+
+// F24_8 returns f as 24.8, rounded to nearest.
+func F24_8(f float64) Int24_8 { return Int24_8(math.Floor(f*(1<<8) + 0.5)) }
+
+// I8_8 returns i as 8.8.
+func I8_8(i int) Int8_8 { return Int8_8(i << 8) }
+
+// F8_8 returns f as 8.8, rounded to nearest.
+func F8_8(f float64) Int8_8 { return Int8_8(math.Floor(f*(1<<8) + 0.5)) }
+
+// Int returns the integer part, rounded down.
+func (x Int16_16) Int() int { return int(x >> 16) }
+
+// Round returns x rounded to the nearest integer.
+func (x Int16_16) Round() int { return int((x + 1<<15) >> 16) }
+
+// Frac returns the fraction, 0 -> 65535.
+func (x Int16_16) Frac() int { return int(x & 0xffff) }
+
+// Float returns x as a float.
+func (x Int16_16) Float() float64 { return float64(x) / (1 << 16) }
+
+// Mul returns x * y.
+func (x Int16_16) Mul(y Int16_16) Int16_16 { return Int16_16(int64(x) * int64(y) >> 16) }
+
+// MulInt returns x * i.
+// Use it instead of converting i to the fixed type, which would read as x * y.
+func (x Int16_16) MulInt(i int) Int16_16 { return Int16_16(int64(x) * int64(i)) }
+
+// Div returns x / y. y must not be 0.
+func (x Int16_16) Div(y Int16_16) Int16_16 { return Int16_16(int64(x) << 16 / int64(y)) }
+
+// SatAdd returns x + y, clamped to the range of the type.
+func (x Int16_16) SatAdd(y Int16_16) Int16_16 { return Int16_16(sat32(int64(x) + int64(y))) }
+
+// SatSub returns x - y, clamped to the range of the type.
+func (x Int16_16) SatSub(y Int16_16) Int16_16 { return Int16_16(sat32(int64(x) - int64(y))) }
+
+// SatMul returns x * y, clamped to the range of the type.
+func (x Int16_16) SatMul(y Int16_16) Int16_16 { return Int16_16(sat32(int64(x) * int64(y) >> 16)) }
+
+// To24_8 returns x as 24.8, rounded down.
+func (x Int16_16) To24_8() Int24_8 { return Int24_8(x >> 8) }
+
+// To8_8 returns x as 8.8, rounded down and clamped to the range of 8.8.
+func (x Int16_16) To8_8() Int8_8 { return Int8_8(sat16(int64(x >> 8))) }
+
+func (x Int16_16) String() string { return format(int64(x), 16) }
+
+// Int returns the integer part, rounded down.
+func (x Int24_8) Int() int { return int(x >> 8) }
+
+// Round returns x rounded to the nearest integer.
+func (x Int24_8) Round() int { return int((x + 1<<7) >> 8) }
+
+// Frac returns the fraction, 0 -> 255.
+func (x Int24_8) Frac() int { return int(x & 0xff) }
+
+// Float returns x as a float.
+func (x Int24_8) Float() float64 { return float64(x) / (1 << 8) }
+
+// Mul returns x * y.
+func (x Int24_8) Mul(y Int24_8) Int24_8 { return Int24_8(int64(x) * int64(y) >> 8) }
+
+// MulInt returns x * i.
+// Use it instead of converting i to the fixed type, which would read as x * y.
+func (x Int24_8) MulInt(i int) Int24_8 { return Int24_8(int64(x) * int64(i)) }
+
+// Div returns x / y. y must not be 0.
+func (x Int24_8) Div(y Int24_8) Int24_8 { return Int24_8(int64(x) << 8 / int64(y)) }
+
+// SatAdd returns x + y, clamped to the range of the type.
+func (x Int24_8) SatAdd(y Int24_8) Int24_8 { return Int24_8(sat32(int64(x) + int64(y))) }
+
+// SatSub returns x - y, clamped to the range of the type.
+func (x Int24_8) SatSub(y Int24_8) Int24_8 { return Int24_8(sat32(int64(x) - int64(y))) }
+
+// SatMul returns x * y, clamped to the range of the type.
+func (x Int24_8) SatMul(y Int24_8) Int24_8 { return Int24_8(sat32(int64(x) * int64(y) >> 8)) }
+
+// To16_16 returns x as 16.16, clamped to the range of 16.16.
+func (x Int24_8) To16_16() Int16_16 { return Int16_16(sat32(int64(x) << 8)) }
+
+// To8_8 returns x as 8.8, clamped to the range of 8.8.
+func (x Int24_8) To8_8() Int8_8 { return Int8_8(sat16(int64(x))) }
+
+func (x Int24_8) String() string { return format(int64(x), 8) }
+
+// Int returns the integer part, rounded down.
+func (x Int8_8) Int() int { return int(x >> 8) }
+
+// Round returns x rounded to the nearest integer.
+func (x Int8_8) Round() int { return int((int32(x) + 1<<7) >> 8) }
+
+// Frac returns the fraction, 0 -> 255.
+func (x Int8_8) Frac() int { return int(x & 0xff) }
+
+// Float returns x as a float.
+func (x Int8_8) Float() float64 { return float64(x) / (1 << 8) }
+
+// Mul returns x * y.
+func (x Int8_8) Mul(y Int8_8) Int8_8 { return Int8_8(int32(x) * int32(y) >> 8) }
+
+// MulInt returns x * i.
+// Use it instead of converting i to the fixed type, which would read as x * y.
+func (x Int8_8) MulInt(i int) Int8_8 { return Int8_8(int64(x) * int64(i)) }
+
+// Div returns x / y. y must not be 0.
+func (x Int8_8) Div(y Int8_8) Int8_8 { return Int8_8(int32(x) << 8 / int32(y)) }
+
+// SatAdd returns x + y, clamped to the range of the type.
+func (x Int8_8) SatAdd(y Int8_8) Int8_8 { return Int8_8(sat16(int64(x) + int64(y))) }
+
+// SatSub returns x - y, clamped to the range of the type.
+func (x Int8_8) SatSub(y Int8_8) Int8_8 { return Int8_8(sat16(int64(x) - int64(y))) }
+
+// SatMul returns x * y, clamped to the range of the type.
+func (x Int8_8) SatMul(y Int8_8) Int8_8 { return Int8_8(sat16(int64(x) * int64(y) >> 8)) }
+
+// To16_16 returns x as 16.16.
+func (x Int8_8) To16_16() Int16_16 { return Int16_16(x) << 8 }
+
+// To24_8 returns x as 24.8.
+func (x Int8_8) To24_8() Int24_8 { return Int24_8(x) }
+
+func (x Int8_8) String() string { return format(int64(x), 8) }
+
+func sat32(v int64) int32 {
+	if v > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if v < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(v)
+}
+
+func sat16(v int64) int16 {
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(v)
+}
+
+// format returns v as the integer and the fraction in hex,
+// so the raw bits are easy to read.
+func format(v int64, bits uint) string {
+	return fmt.Sprintf("(%d,0x%0*x)", v>>bits, bits/4, v&(1<<bits-1))
+}
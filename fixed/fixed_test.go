@@ -0,0 +1,154 @@
+package fixed
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSinCos(t *testing.T) {
+	const maxErr = 2.0 / 65536
+	var worst float64
+	for i := 0; i < 65536; i++ {
+		a := Angle(i)
+		s := math.Abs(Sin(a).Float() - math.Sin(a.Radians()))
+		c := math.Abs(Cos(a).Float() - math.Cos(a.Radians()))
+		worst = math.Max(worst, math.Max(s, c))
+		if s > maxErr || c > maxErr {
+			t.Fatalf("angle %d: sin error %g, cos error %g", i, s*65536, c*65536)
+		}
+	}
+	t.Logf("max error %.3f/65536", worst*65536)
+}
+
+func TestAtan2(t *testing.T) {
+	const maxErr = 0.0001
+	axes := []struct {
+		y, x int32
+		want Angle
+	}{
+		{0, 0, 0},
+		{0, 1, 0},
+		{1, 0, 1 << 14},
+		{0, -1, 1 << 15},
+		{-1, 0, 3 << 14},
+		{5, 5, 1 << 13},
+		{-5, -5, 5 << 13},
+	}
+	for _, v := range axes {
+		if got := Atan2(v.y, v.x); got != v.want {
+			t.Errorf("Atan2(%d, %d): got %d, want %d", v.y, v.x, got, v.want)
+		}
+	}
+	var worst float64
+	for y := int32(-1 << 20); y <= 1<<20; y += 1<<20/67 + 1 {
+		for x := int32(-1 << 20); x <= 1<<20; x += 1<<20/71 + 1 {
+			if x == 0 && y == 0 {
+				continue
+			}
+			d := Atan2(y, x).Radians() - math.Atan2(float64(y), float64(x))
+			d = math.Abs(math.Remainder(d, 2*math.Pi))
+			worst = math.Max(worst, d)
+			if d > maxErr {
+				t.Fatalf("Atan2(%d, %d): error %g radians", y, x, d)
+			}
+		}
+	}
+	t.Logf("max error %g radians", worst)
+}
+
+func TestSqrt(t *testing.T) {
+	for _, x := range []Int16_16{0, -1, math.MinInt32} {
+		if got := Sqrt(x); got != 0 {
+			t.Errorf("Sqrt(%v): got %v, want 0", x, got)
+		}
+	}
+	if got := Sqrt(I16_16(4)); got != I16_16(2) {
+		t.Errorf("Sqrt(4): got %v, want 2", got)
+	}
+	for x := int64(1); x <= math.MaxInt32; x += x/97 + 1 {
+		g := uint64(Sqrt(Int16_16(x)))
+		n := uint64(x) << 16
+		if g*g > n || (g+1)*(g+1) <= n {
+			t.Fatalf("Sqrt(%v): got %v, not rounded down", Int16_16(x), Int16_16(g))
+		}
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	if got := F16_16(1.5).Mul(F16_16(-2.25)); got != F16_16(-3.375) {
+		t.Errorf("1.5 * -2.25: got %v", got)
+	}
+	if got := F16_16(-3.375).Div(F16_16(1.5)); got != F16_16(-2.25) {
+		t.Errorf("-3.375 / 1.5: got %v", got)
+	}
+	if got := F24_8(1.5).Mul(F24_8(-2.25)); got != F24_8(-3.375) {
+		t.Errorf("24.8 1.5 * -2.25: got %v", got)
+	}
+	if got := F8_8(-3.375).Div(F8_8(1.5)); got != F8_8(-2.25) {
+		t.Errorf("8.8 -3.375 / 1.5: got %v", got)
+	}
+
+	if got := F16_16(1.5).MulInt(-3); got != F16_16(-4.5) {
+		t.Errorf("1.5 * -3: got %v", got)
+	}
+	if got := F24_8(-0.25).MulInt(10); got != F24_8(-2.5) {
+		t.Errorf("24.8 -0.25 * 10: got %v", got)
+	}
+	if got := F8_8(0.5).MulInt(7); got != F8_8(3.5) {
+		t.Errorf("8.8 0.5 * 7: got %v", got)
+	}
+
+	// Plain Mul wraps around, SatMul clamps.
+	big := I16_16(300)
+	if got, want := big.Mul(big), Int16_16(90000<<16-1<<32); got != want {
+		t.Errorf("300 * 300: got %v, want it to wrap to %v", got, want)
+	}
+	if got := big.SatMul(big); got != math.MaxInt32 {
+		t.Errorf("SatMul 300 * 300: got %v, want max", got)
+	}
+	if got := big.SatMul(-big); got != math.MinInt32 {
+		t.Errorf("SatMul 300 * -300: got %v, want min", got)
+	}
+	if got := I8_8(100).SatMul(I8_8(100)); got != math.MaxInt16 {
+		t.Errorf("8.8 SatMul 100 * 100: got %v, want max", got)
+	}
+
+	// Saturation at the ends of the range.
+	max, min := Int16_16(math.MaxInt32), Int16_16(math.MinInt32)
+	if got := max.SatAdd(1); got != max {
+		t.Errorf("max + 1: got %v", got)
+	}
+	if got := min.SatSub(1); got != min {
+		t.Errorf("min - 1: got %v", got)
+	}
+	if got := min.SatAdd(-1); got != min {
+		t.Errorf("min + -1: got %v", got)
+	}
+	if got := max.SatMul(I16_16(1)); got != max {
+		t.Errorf("max * 1: got %v", got)
+	}
+	if got := min.SatMul(I16_16(-1)); got != max {
+		t.Errorf("min * -1: got %v", got)
+	}
+	if got := Int8_8(math.MaxInt16).SatAdd(1); got != math.MaxInt16 {
+		t.Errorf("8.8 max + 1: got %v", got)
+	}
+	if got := Int24_8(math.MinInt32).SatSub(1); got != math.MinInt32 {
+		t.Errorf("24.8 min - 1: got %v", got)
+	}
+	if got := I24_8(1 << 20).To16_16(); got != max {
+		t.Errorf("24.8 to 16.16: got %v, want max", got)
+	}
+	if got := I16_16(-1000).To8_8(); got != math.MinInt16 {
+		t.Errorf("16.16 to 8.8: got %v, want min", got)
+	}
+}
+
+func TestDivZero(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("dividing by 0 should panic")
+		}
+	}()
+	I16_16(1).Div(0)
+}
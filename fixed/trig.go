@@ -0,0 +1,138 @@
+package fixed
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Angle is an angle where 65536 is a full circle.
+// It wraps around by itself, so angles never need to be reduced.
+type Angle uint16
+
+// Radians returns the angle of r radians.
+func Radians(r float64) Angle {
+	return Angle(int64(math.Floor(r*(65536/(2*math.Pi))+0.5)) & 0xffff)
+}
+
+// Radians returns the angle in radians, 0 -> 2*Pi.
+func (a Angle) Radians() float64 {
+	return float64(a) * (2 * math.Pi / 65536)
+}
+
+const (
+	sinBits = 10
+	sinSize = 1 << sinBits
+	// Bits of the angle used for interpolation.
+	sinFrac = 16 - sinBits
+)
+
+// sinTab has the sine for each of sinSize steps of a full circle,
+// plus one, so interpolation never needs to wrap.
+var sinTab [sinSize + 1]int32
+
+const atanSize = 256
+
+// atanTab has atan(i / atanSize) as Angle, for i in 0 -> atanSize.
+var atanTab [atanSize + 1]int32
+
+// sqrtTab has sqrt(i) * 256 for the first 9 bits of a value.
+var sqrtTab [512]uint32
+
+func init() {
+	for i := range sinTab {
+		sinTab[i] = int32(math.Floor(math.Sin(float64(i)*2*math.Pi/sinSize)*(1<<16) + 0.5))
+	}
+	for i := range atanTab {
+		atanTab[i] = int32(math.Floor(math.Atan(float64(i)/atanSize)*(65536/(2*math.Pi)) + 0.5))
+	}
+	for i := range sqrtTab {
+		sqrtTab[i] = uint32(math.Sqrt(float64(i)) * 256)
+	}
+}
+
+// Sin returns the sine of a, using linear interpolation in a table.
+// The error is at most 2/65536.
+func Sin(a Angle) Int16_16 {
+	i := a >> sinFrac
+	f := int32(a & (1<<sinFrac - 1))
+	s0, s1 := sinTab[i], sinTab[i+1]
+	return Int16_16(s0 + ((s1-s0)*f+1<<(sinFrac-1))>>sinFrac)
+}
+
+// Cos returns the cosine of a. The error is at most 2/65536.
+func Cos(a Angle) Int16_16 {
+	return Sin(a + 1<<14)
+}
+
+// Atan2 returns the angle of the vector (x, y), like math.Atan2.
+// Any fixed point format can be used, as long as x and y have the same.
+// The error is at most 1, about 0.0001 radians.
+func Atan2(y, x int32) Angle {
+	if x == 0 && y == 0 {
+		return 0
+	}
+	ax, ay := int64(x), int64(y)
+	if ax < 0 {
+		ax = -ax
+	}
+	if ay < 0 {
+		ay = -ay
+	}
+	// Reduce to the first octant, so the ratio is 0 -> 1.
+	swap := ay > ax
+	if swap {
+		ax, ay = ay, ax
+	}
+	r := ay * atanSize << 16 / ax
+	i, f := r>>16, int32(r&0xffff)
+	a := atanTab[i]
+	if i < atanSize {
+		a += ((atanTab[i+1]-a)*f + 1<<15) >> 16
+	}
+	if swap {
+		a = 1<<14 - a
+	}
+	if x < 0 {
+		a = 1<<15 - a
+	}
+	if y < 0 {
+		a = -a
+	}
+	return Angle(a)
+}
+
+// Sqrt returns the square root of x, rounded down.
+// Negative values return 0.
+func Sqrt(x Int16_16) Int16_16 {
+	if x <= 0 {
+		return 0
+	}
+	// sqrt(x / 65536) * 65536 = sqrt(x * 65536)
+	return Int16_16(isqrt(uint64(x) << 16))
+}
+
+// isqrt returns the square root of n, rounded down.
+// A guess from a table is refined with Newton's method.
+func isqrt(n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+	// Shift, by an even amount, so 8 or 9 bits are left.
+	shift := uint(0)
+	if l := bits.Len64(n); l > 9 {
+		shift = uint(l-8) &^ 1
+	}
+	g := uint64(sqrtTab[n>>shift]) << (shift / 2) >> 8
+	if g == 0 {
+		g = 1
+	}
+	g = (g + n/g) >> 1
+	g = (g + n/g) >> 1
+	for g*g > n {
+		g--
+	}
+	for (g+1)*(g+1) <= n {
+		g++
+	}
+	return g
+}
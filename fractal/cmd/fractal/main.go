@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math"
+
+	"github.com/klauspost/gad/fractal"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+// zoom goes into the seahorse valley and back out.
+var zoom = fractal.Path(
+	fractal.Key{T: 0, View: fractal.View{X: -0.5, Zoom: 1}},
+	fractal.Key{T: 0.1, View: fractal.View{X: -0.5, Zoom: 1}},
+	fractal.Key{T: 0.6, View: fractal.View{X: -0.743643887, Y: 0.131825904, Zoom: 50000}},
+	fractal.Key{T: 0.7, View: fractal.View{X: -0.743643887, Y: 0.131825904, Zoom: 50000}},
+	fractal.Key{T: 1, View: fractal.View{X: -0.5, Zoom: 1}},
+)
+
+func main() {
+	fx, err := fractal.New(renderWidth, renderHeight, fractal.Config{
+		View:       zoom,
+		Iterations: 512,
+		Cycle:      2,
+		// Limit the work per frame, refining over the next frames:
+		//Budget: 100,
+	})
+	// Animated Julia set:
+	//fx, err := fractal.New(renderWidth, renderHeight, fractal.Config{Julia: julia, Cycle: 1})
+	if err != nil {
+		panic(err)
+	}
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
+
+// julia returns a constant moving around the edge of the main cardioid.
+func julia(t float64) (x, y float64) {
+	a := t * 2 * math.Pi
+	return 0.7885 * math.Cos(a), 0.7885 * math.Sin(a)
+}
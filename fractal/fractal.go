@@ -0,0 +1,392 @@
+// Package fractal renders Mandelbrot and Julia sets with smooth colors.
+//
+// The iteration uses 64 bit fixed point with 26 fraction bits,
+// which is exact and fast, but limits the zoom to about 100000.
+// Lines are calculated on all cores.
+//
+// Like XaoS, each column and row remembers the coordinate it was calculated at.
+// When the view changes, columns and rows close to an old one
+// are copied from the previous frame, so only new detail is calculated.
+package fractal
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/gad/texture"
+)
+
+// Palette is a cycling palette for fractals.
+var Palette = texture.NewPalette(true,
+	color.RGBA{G: 10, B: 60, A: 255},
+	color.RGBA{R: 30, G: 120, B: 210, A: 255},
+	color.RGBA{R: 240, G: 250, B: 255, A: 255},
+	color.RGBA{R: 250, G: 170, B: 20, A: 255},
+	color.RGBA{R: 90, G: 20, B: 20, A: 255},
+)
+
+// Config describes a fractal effect.
+type Config struct {
+	// View returns the view at time t, see Path.
+	// If nil, the whole set is shown.
+	View func(t float64) View
+
+	// Julia returns the constant of a Julia set at time t.
+	// If nil, the Mandelbrot set is rendered.
+	// When the constant changes, the whole frame is calculated.
+	Julia func(t float64) (x, y float64)
+
+	// Iterations is the maximum number of iterations. 0 gives 256.
+	Iterations int
+
+	// Palette is stretched to 255 entries. If nil, Palette is used.
+	// Use a cycling palette, texture.NewPalette(true, ...), since colors repeat.
+	Palette color.Palette
+
+	// Inside is the color of the set. If nil, black is used.
+	Inside color.Color
+
+	// Density is the number of palette entries per iteration. 0 gives 8.
+	Density float64
+
+	// Cycle is the number of full palette rotations per unit of time.
+	Cycle float64
+
+	// Budget is the maximum number of new columns and rows calculated per frame.
+	// Columns and rows over the budget are copied from the nearest old one,
+	// and are calculated in a later frame.
+	// If 0, all new columns and rows are calculated.
+	Budget int
+}
+
+const (
+	fracBits = 26
+	// Bail out when |z|^2 is above this.
+	bailout = 16 << fracBits
+	// Coordinates are clamped to this, so the iteration can't overflow.
+	maxCoord = 16
+	// Smooth values have this many fraction bits.
+	smoothBits = 8
+	// inside is the value of points in the set.
+	inside = 0
+)
+
+// Fx is a fractal effect.
+type Fx struct {
+	cfg  Config
+	w, h int
+	// Smooth iteration count for each pixel, current and previous frame.
+	vals, old []uint32
+	// Coordinate each column and row was calculated at.
+	// Rows are stored top to bottom, so they are the negative imaginary part.
+	colX, rowY []float64
+	// Scratch for the next coordinates.
+	nextX, nextY []float64
+	// Column and row in the previous frame to copy from.
+	srcCol, srcRow []int
+	// Columns and rows that are calculated this frame.
+	calcCol, calcRow []bool
+	// Fixed point coordinate of each column and row.
+	fixCol, fixRow []int64
+	valid          bool
+	juliaX, juliaY float64
+	// smooth is the fraction of an iteration to subtract, indexed by |z|^2 in quarters.
+	smooth []uint16
+	draw   *image.Paletted
+	lines  [][]byte
+}
+
+// New returns a fractal rendering w * h pixels.
+func New(w, h int, c Config) (*Fx, error) {
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("fractal: invalid size")
+	}
+	if c.View == nil {
+		v := View{X: -0.5, Zoom: 1}
+		if c.Julia != nil {
+			v.X = 0
+		}
+		c.View = func(float64) View { return v }
+	}
+	if c.Iterations <= 0 {
+		c.Iterations = 256
+	}
+	if len(c.Palette) == 0 {
+		c.Palette = Palette
+	}
+	if c.Inside == nil {
+		c.Inside = color.RGBA{A: 255}
+	}
+	if c.Density == 0 {
+		c.Density = 8
+	}
+	fx := Fx{
+		cfg:     c,
+		w:       w,
+		h:       h,
+		vals:    make([]uint32, w*h),
+		old:     make([]uint32, w*h),
+		colX:    make([]float64, w),
+		rowY:    make([]float64, h),
+		nextX:   make([]float64, w),
+		nextY:   make([]float64, h),
+		srcCol:  make([]int, w),
+		srcRow:  make([]int, h),
+		calcCol: make([]bool, w),
+		calcRow: make([]bool, h),
+		fixCol:  make([]int64, w),
+		fixRow:  make([]int64, h),
+	}
+
+	// The largest |z|^2 is below 2048, see iterate.
+	fx.smooth = make([]uint16, 2048<<2+1)
+	for i := range fx.smooth {
+		m := (float64(i) + 0.5) / 4
+		if m <= 16 {
+			continue
+		}
+		// Fraction of an iteration left, so the count is continuous.
+		f := math.Log2(math.Log2(m) / 4)
+		fx.smooth[i] = uint16(f * (1 << smoothBits))
+	}
+
+	// Inside color first, then the palette stretched to the rest.
+	pal := make(color.Palette, 256)
+	pal[0] = c.Inside
+	for i := 1; i < len(pal); i++ {
+		pal[i] = c.Palette[(i-1)*len(c.Palette)/255]
+	}
+
+	// Create our draw buffer
+	fx.draw = image.NewPaletted(image.Rect(0, 0, w, h), pal)
+
+	// Store each line as a slice in a slice.
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+	return &fx, nil
+}
+
+// Render the effect at time t.
+func (fx *Fx) Render(t float64) image.Image {
+	v := fx.cfg.View(t)
+	w, h := fx.w, fx.h
+	step := 3 / (float64(h) * v.Zoom)
+	for x := range fx.nextX {
+		fx.nextX[x] = v.X + (float64(x)+0.5-float64(w)/2)*step
+	}
+	for y := range fx.nextY {
+		fx.nextY[y] = -v.Y + (float64(y)+0.5-float64(h)/2)*step
+	}
+
+	var jx, jy float64
+	julia := fx.cfg.Julia != nil
+	if julia {
+		jx, jy = fx.cfg.Julia(t)
+		if jx != fx.juliaX || jy != fx.juliaY {
+			fx.valid = false
+		}
+		fx.juliaX, fx.juliaY = jx, jy
+	}
+
+	if fx.valid {
+		fx.reuse(step)
+	} else {
+		for i := range fx.calcCol {
+			fx.calcCol[i] = true
+		}
+		for i := range fx.calcRow {
+			fx.calcRow[i] = true
+		}
+		fx.valid = true
+	}
+
+	// Update coordinates of all lines.
+	for x, want := range fx.nextX {
+		if !fx.calcCol[x] {
+			want = fx.colX[fx.srcCol[x]]
+		}
+		fx.nextX[x] = want
+		fx.fixCol[x] = toFixed(want)
+	}
+	for y, want := range fx.nextY {
+		if !fx.calcRow[y] {
+			want = fx.rowY[fx.srcRow[y]]
+		}
+		fx.nextY[y] = want
+		fx.fixRow[y] = toFixed(-want)
+	}
+	fx.colX, fx.nextX = fx.nextX, fx.colX
+	fx.rowY, fx.nextY = fx.nextY, fx.rowY
+	fx.vals, fx.old = fx.old, fx.vals
+
+	cx, cy := toFixed(jx), toFixed(jy)
+	point := func(x, y int64) uint32 {
+		if julia {
+			return fx.iterate(x, y, cx, cy)
+		}
+		return fx.iterate(x, y, x, y)
+	}
+
+	dens := int(fx.cfg.Density * (1 << (16 - smoothBits)))
+	rot := int(math.Floor(t*fx.cfg.Cycle*255)) % 255
+	if rot < 0 {
+		rot += 255
+	}
+	parallel(h, func(y int) {
+		line := fx.vals[y*w : (y+1)*w]
+		py := fx.fixRow[y]
+		if fx.calcRow[y] {
+			for x := range line {
+				line[x] = point(fx.fixCol[x], py)
+			}
+		} else {
+			old := fx.old[fx.srcRow[y]*w : (fx.srcRow[y]+1)*w]
+			for x := range line {
+				if fx.calcCol[x] {
+					line[x] = point(fx.fixCol[x], py)
+					continue
+				}
+				line[x] = old[fx.srcCol[x]]
+			}
+		}
+		dst := fx.lines[y]
+		for x, s := range line {
+			if s == inside {
+				dst[x] = 0
+				continue
+			}
+			dst[x] = uint8(1 + (int(s)*dens>>16+rot)%255)
+		}
+	})
+	return fx.draw
+}
+
+// line is a column or row that needs to be calculated.
+type line struct {
+	row   bool
+	index int
+	err   float64
+}
+
+// reuse finds the old columns and rows to copy from and
+// marks the ones that must be calculated.
+func (fx *Fx) reuse(step float64) {
+	var lines []line
+	lines = match(fx.colX, fx.nextX, fx.srcCol, fx.calcCol, step, false, lines)
+	lines = match(fx.rowY, fx.nextY, fx.srcRow, fx.calcRow, step, true, lines)
+	if fx.cfg.Budget <= 0 || len(lines) <= fx.cfg.Budget {
+		return
+	}
+	// Calculate the lines that are furthest off.
+	sort.Slice(lines, func(i, j int) bool { return lines[i].err > lines[j].err })
+	for _, l := range lines[fx.cfg.Budget:] {
+		if l.row {
+			fx.calcRow[l.index] = false
+		} else {
+			fx.calcCol[l.index] = false
+		}
+	}
+}
+
+// match finds the nearest old coordinate for each wanted coordinate.
+// Lines that are more than half a step off, or where another line is closer
+// to the same old line, are marked to be calculated and appended to lines.
+func match(old, want []float64, src []int, calc []bool, step float64, row bool, lines []line) []line {
+	k := 0
+	for i, v := range want {
+		// Both are sorted, so the nearest only moves forward.
+		for k+1 < len(old) && math.Abs(old[k+1]-v) <= math.Abs(old[k]-v) {
+			k++
+		}
+		src[i] = k
+		calc[i] = math.Abs(old[k]-v) >= step/2
+	}
+	// Only the closest line copies an old line exactly.
+	for i := 0; i < len(want); {
+		end, best := i+1, i
+		for end < len(want) && src[end] == src[i] {
+			if math.Abs(old[src[end]]-want[end]) < math.Abs(old[src[best]]-want[best]) {
+				best = end
+			}
+			end++
+		}
+		for j := i; j < end; j++ {
+			if j != best {
+				calc[j] = true
+			}
+		}
+		i = end
+	}
+	for i, c := range calc {
+		if c {
+			lines = append(lines, line{row: row, index: i, err: math.Abs(old[src[i]] - want[i])})
+		}
+	}
+	return lines
+}
+
+// iterate returns the smooth iteration count of z = z^2 + c,
+// starting at z, or inside if it doesn't escape.
+// Coordinates are within ±16, so after the last iteration
+// that doesn't escape, components are within ±32 and
+// the squares fit in 64 bits.
+func (fx *Fx) iterate(zx, zy, cx, cy int64) uint32 {
+	for n := 0; n < fx.cfg.Iterations; n++ {
+		x2 := zx * zx >> fracBits
+		y2 := zy * zy >> fracBits
+		if m := x2 + y2; m > bailout {
+			i := int(m >> (fracBits - 2))
+			if i >= len(fx.smooth) {
+				i = len(fx.smooth) - 1
+			}
+			s := (n+1)<<smoothBits - int(fx.smooth[i])
+			if s < 1 {
+				s = 1
+			}
+			return uint32(s)
+		}
+		zy = zx*zy>>(fracBits-1) + cy
+		zx = x2 - y2 + cx
+	}
+	return inside
+}
+
+// toFixed returns v as fixed point, clamped to ±maxCoord.
+func toFixed(v float64) int64 {
+	if v > maxCoord {
+		v = maxCoord
+	}
+	if v < -maxCoord {
+		v = -maxCoord
+	}
+	return int64(math.Floor(v*(1<<fracBits) + 0.5))
+}
+
+// parallel calls fn for each line 0 -> n-1, using all cores.
+// Lines are handed out one at a time, since some are much slower than others.
+func parallel(n int, fn func(y int)) {
+	var wg sync.WaitGroup
+	next := int32(-1)
+	for i := runtime.GOMAXPROCS(0); i > 0; i-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				y := int(atomic.AddInt32(&next, 1))
+				if y >= n {
+					return
+				}
+				fn(y)
+			}
+		}()
+	}
+	wg.Wait()
+}
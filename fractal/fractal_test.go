@@ -0,0 +1,172 @@
+package fractal
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// testPath zooms in, pans and zooms out again.
+var testPath = Path(
+	Key{T: 0, View: View{X: -0.5, Zoom: 1}},
+	Key{T: 1, View: View{X: -0.7436, Y: 0.1318, Zoom: 40}},
+	Key{T: 1.5, View: View{X: -0.7, Y: 0.2, Zoom: 40}},
+	Key{T: 2, View: View{X: -0.2, Y: 0.5, Zoom: 3}},
+)
+
+// problems compares a frame rendered with reuse to a fresh full render.
+// Copied lines may be up to half a step from where the fresh render calculated them,
+// and every pixel must be the exact value at the coordinate its lines were calculated at.
+// It returns the number of copied lines and a description of each problem.
+func problems(got, fresh *Fx, step float64) (copied int, probs []string) {
+	check := func(what string, i int, have, want float64) {
+		if have == want {
+			return
+		}
+		copied++
+		if math.Abs(have-want) >= step/2 {
+			probs = append(probs, fmt.Sprintf("%s %d: at %v, want %v ± %v", what, i, have, want, step/2))
+		}
+	}
+	for x := range got.colX {
+		check("column", x, got.colX[x], fresh.colX[x])
+	}
+	for y := range got.rowY {
+		check("row", y, got.rowY[y], fresh.rowY[y])
+	}
+	for y, py := range got.fixRow {
+		for x, px := range got.fixCol {
+			if v, want := got.vals[y*got.w+x], got.iterate(px, py, px, py); v != want {
+				probs = append(probs, fmt.Sprintf("(%d,%d): got %d, want %d", x, y, v, want))
+				continue
+			}
+			if got.colX[x] == fresh.colX[x] && got.rowY[y] == fresh.rowY[y] && got.vals[y*got.w+x] != fresh.vals[y*fresh.w+x] {
+				probs = append(probs, fmt.Sprintf("(%d,%d): got %d, fresh render %d", x, y, got.vals[y*got.w+x], fresh.vals[y*fresh.w+x]))
+			}
+		}
+	}
+	return copied, probs
+}
+
+func TestReuse(t *testing.T) {
+	const w, h = 96, 64
+	c := Config{View: testPath, Iterations: 64}
+	fx, err := New(w, h, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := 0
+	for i := 0; i <= 60; i++ {
+		tm := float64(i) / 30
+		fx.Render(tm)
+		fresh, err := New(w, h, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fresh.Render(tm)
+		n, probs := problems(fx, fresh, 3/(h*testPath(tm).Zoom))
+		for _, p := range probs {
+			t.Errorf("t=%v: %s", tm, p)
+		}
+		if len(probs) > 0 {
+			return
+		}
+		copied += n
+	}
+	if copied == 0 {
+		t.Error("no lines were copied")
+	}
+}
+
+func TestBudgetConverges(t *testing.T) {
+	const w, h = 96, 64
+	// At most 4 frames to calculate every column and row.
+	c := Config{View: testPath, Iterations: 64, Budget: (w + h) / 4}
+	fx, err := New(w, h, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Jump from zoomed in to zoomed out, so almost every line is off.
+	fx.Render(1)
+	const tm = 2
+	fresh, err := New(w, h, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh.Render(tm)
+	step := 3 / (h * testPath(tm).Zoom)
+	for frame := 0; frame < 5; frame++ {
+		fx.Render(tm)
+		_, probs := problems(fx, fresh, step)
+		if frame == 0 && len(probs) == 0 {
+			t.Fatal("first frame is complete, the budget was not used")
+		}
+		if len(probs) == 0 {
+			return
+		}
+		if frame == 4 {
+			for _, p := range probs {
+				t.Error(p)
+			}
+		}
+	}
+}
+
+// smoothRef is the smooth iteration count of z = z^2 + c in float64,
+// or 0 if it doesn't escape.
+func smoothRef(zx, zy, cx, cy float64, iterations int) float64 {
+	for n := 0; n < iterations; n++ {
+		if m := zx*zx + zy*zy; m > 16 {
+			return math.Max(float64(n+1)-math.Log2(math.Log2(m)/4), 1.0/(1<<smoothBits))
+		}
+		zx, zy = zx*zx-zy*zy+cx, 2*zx*zy+cy
+	}
+	return 0
+}
+
+func TestIterate(t *testing.T) {
+	fx, err := New(1, 1, Config{Iterations: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The table has quarter steps and values are truncated.
+	const tolerance = 3.0 / (1 << smoothBits)
+	test := func(zx, zy, cx, cy float64) {
+		got := fx.iterate(toFixed(zx), toFixed(zy), toFixed(cx), toFixed(cy))
+		clamp := func(v float64) float64 { return math.Max(-maxCoord, math.Min(maxCoord, v)) }
+		want := smoothRef(clamp(zx), clamp(zy), clamp(cx), clamp(cy), fx.cfg.Iterations)
+		if want == 0 {
+			if got != inside {
+				t.Errorf("z=(%v,%v) c=(%v,%v): got %v, want inside", zx, zy, cx, cy, got)
+			}
+			return
+		}
+		if d := math.Abs(float64(got)/(1<<smoothBits) - want); got == inside || d > tolerance {
+			t.Errorf("z=(%v,%v) c=(%v,%v): got %v, want %v", zx, zy, cx, cy, float64(got)/(1<<smoothBits), want)
+		}
+	}
+	// Near and beyond the clamp, Mandelbrot and Julia starting points.
+	edges := []float64{-20, -16, -15.99, -15.5, -12.3, 12.3, 15.5, 15.99, 16, 20}
+	for _, a := range edges {
+		for _, b := range edges {
+			test(a, b, a, b)
+			test(a, b, -0.8, 0.156)
+			test(0, 0, a, b)
+		}
+		test(a, 0, a, 0)
+		test(0, a, 0, a)
+		test(a, 0.1, -0.4, 0.6)
+	}
+	// A coarse grid over the clamped range.
+	for y := -16.0; y <= 16; y += 0.37 {
+		for x := -16.0; x <= 16; x += 0.37 {
+			test(x, y, x, y)
+		}
+	}
+	// Points of the set and points that escape slowly.
+	test(0, 0, -1, 0)
+	test(-2, 0, -2, 0)
+	test(0.25, 0, 0.25, 0)
+	test(0.3, 0, 0.3, 0)
+	test(-0.75, 0.1, -0.75, 0.1)
+}
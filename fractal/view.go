@@ -0,0 +1,57 @@
+package fractal
+
+import (
+	"math"
+)
+
+// View is the part of the plane shown on screen.
+type View struct {
+	// Center of the screen.
+	X, Y float64
+
+	// Zoom 1 shows 3 units from the top to the bottom of the screen.
+	// It must be above 0.
+	Zoom float64
+}
+
+// Key is a view at a time.
+type Key struct {
+	T float64
+	View
+}
+
+// Path returns views moving through the keys, which must be sorted by time.
+// Before the first and after the last key the view doesn't move.
+//
+// The zoom changes at a constant rate between keys and the center
+// moves so the point zoomed towards stays at the same place on screen.
+// Each move eases in and out.
+func Path(keys ...Key) func(t float64) View {
+	return func(t float64) View {
+		if len(keys) == 0 {
+			return View{X: -0.5, Zoom: 1}
+		}
+		if t <= keys[0].T {
+			return keys[0].View
+		}
+		for i := 1; i < len(keys); i++ {
+			a, b := keys[i-1], keys[i]
+			if t >= b.T {
+				continue
+			}
+			f := (t - a.T) / (b.T - a.T)
+			f = f * f * (3 - 2*f)
+			v := View{Zoom: a.Zoom * math.Pow(b.Zoom/a.Zoom, f)}
+			// Move the center by the change of the screen size,
+			// so the zoom follows a straight line.
+			s := f
+			if a.Zoom != b.Zoom {
+				s = (1/v.Zoom - 1/a.Zoom) / (1/b.Zoom - 1/a.Zoom)
+			}
+			v.X = a.X + (b.X-a.X)*s
+			v.Y = a.Y + (b.Y-a.Y)*s
+			return v
+		}
+		return keys[len(keys)-1].View
+	}
+}
@@ -0,0 +1,67 @@
+package fractal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPathEndpoints(t *testing.T) {
+	keys := []Key{
+		{T: 0.1, View: View{X: -0.5, Y: 0, Zoom: 1}},
+		{T: 0.5, View: View{X: -0.743643, Y: 0.131825, Zoom: 5000}},
+		{T: 0.9, View: View{X: -0.74, Y: 0.13, Zoom: 2}},
+	}
+	path := Path(keys...)
+	for _, k := range keys {
+		if got := path(k.T); got != k.View {
+			t.Errorf("t=%v: got %+v, want %+v", k.T, got, k.View)
+		}
+	}
+	for _, tm := range []float64{-1, 0, 0.1} {
+		if got := path(tm); got != keys[0].View {
+			t.Errorf("t=%v: got %+v, want first key", tm, got)
+		}
+	}
+	for _, tm := range []float64{0.9, 1, 2} {
+		if got := path(tm); got != keys[2].View {
+			t.Errorf("t=%v: got %+v, want last key", tm, got)
+		}
+	}
+	// Close to the keys the view is close to the key.
+	for _, k := range keys {
+		for _, d := range []float64{-1e-6, 1e-6} {
+			v := path(k.T + d)
+			if math.Abs(v.Zoom/k.Zoom-1) > 1e-6 || math.Abs(v.X-k.X)*k.Zoom > 1e-6 || math.Abs(v.Y-k.Y)*k.Zoom > 1e-6 {
+				t.Errorf("t=%v: got %+v, want close to %+v", k.T+d, v, k.View)
+			}
+		}
+	}
+}
+
+func TestPathZoom(t *testing.T) {
+	path := Path(Key{T: 0, View: View{Zoom: 1}}, Key{T: 1, View: View{X: 1, Y: -1, Zoom: 100}})
+	// Zoom is geometric, so halfway is the geometric mean.
+	if got := path(0.5).Zoom; math.Abs(got-10) > 1e-9 {
+		t.Errorf("halfway zoom: got %v, want 10", got)
+	}
+	prev := path(0)
+	for i := 1; i <= 100; i++ {
+		v := path(float64(i) / 100)
+		if v.Zoom < prev.Zoom || v.X < prev.X || v.Y > prev.Y {
+			t.Fatalf("t=%v: %+v does not move on from %+v", float64(i)/100, v, prev)
+		}
+		prev = v
+	}
+}
+
+func TestPathEmpty(t *testing.T) {
+	if got, want := Path()(0.5), (View{X: -0.5, Zoom: 1}); got != want {
+		t.Errorf("no keys: got %+v, want %+v", got, want)
+	}
+	k := Key{T: 0.3, View: View{X: 1, Y: 2, Zoom: 3}}
+	for _, tm := range []float64{0, 0.3, 1} {
+		if got := Path(k)(tm); got != k.View {
+			t.Errorf("one key, t=%v: got %+v", tm, got)
+		}
+	}
+}
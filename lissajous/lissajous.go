@@ -0,0 +1,33 @@
+// Package lissajous moves objects on Lissajous curves.
+//
+// Each axis follows its own sine, so a few numbers give movement that
+// looks complex, but returns to the start when t loops.
+package lissajous
+
+import "math"
+
+// Curve is a movement around a center with a sine on each axis.
+type Curve struct {
+	// Center of the movement.
+	X, Y, Z float64
+
+	// Amplitude of the movement on each axis.
+	AmpX, AmpY, AmpZ float64
+
+	// Frequency of each axis in periods per unit of time.
+	// The movement only loops with t if all are whole numbers.
+	FreqX, FreqY, FreqZ float64
+
+	// Phase offsets all axes at t = 0, in periods.
+	// Z uses twice the phase, so it doesn't follow X.
+	Phase float64
+}
+
+// At returns the position at time t.
+func (c Curve) At(t float64) (x, y, z float64) {
+	a := c.Phase * 2 * math.Pi
+	x = c.X + c.AmpX*math.Sin(c.FreqX*t*2*math.Pi+a)
+	y = c.Y + c.AmpY*math.Cos(c.FreqY*t*2*math.Pi+a)
+	z = c.Z + c.AmpZ*math.Sin(c.FreqZ*t*2*math.Pi+a*2)
+	return x, y, z
+}
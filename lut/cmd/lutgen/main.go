@@ -0,0 +1,57 @@
+// lutgen writes lookup tables, so they can be embedded with go-bindata.
+//
+// Use it from go:generate before go-bindata, for example:
+//
+//	//go:generate go run ../lut/cmd/lutgen/main.go -w=640 -h=360 -tunnel=8,0.5 -o=./data
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/gad/lut"
+)
+
+var (
+	width  = flag.Int("w", 640, "Width of table")
+	height = flag.Int("h", 360, "Height of table")
+	tunnel = flag.String("tunnel", "", "Write tunnel table with 'ratio,angleScale'")
+	out    = flag.String("o", ".", "Output directory")
+)
+
+func main() {
+	flag.Parse()
+	var tables []lut.Table
+	if *tunnel != "" {
+		var ratio, angle float64
+		if _, err := fmt.Sscanf(*tunnel, "%g,%g", &ratio, &angle); err != nil {
+			exit(fmt.Errorf("parsing -tunnel: %v", err))
+		}
+		tables = append(tables, lut.Tunnel(*width, *height, ratio, angle))
+	}
+	if len(tables) == 0 {
+		exit(fmt.Errorf("no tables specified"))
+	}
+	for _, t := range tables {
+		name := filepath.Join(*out, t.File())
+		f, err := os.Create(name)
+		if err != nil {
+			exit(err)
+		}
+		err = t.Encode(f, t.Generate())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			exit(err)
+		}
+		fmt.Println("Wrote", name)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "lutgen:", err)
+	os.Exit(1)
+}
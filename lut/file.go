@@ -0,0 +1,139 @@
+package lut
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+)
+
+// Stored tables have this header, all little endian:
+//
+//	magic   [8]byte
+//	keyLen  uint16
+//	key     [keyLen]byte
+//	entries uint32
+//	crc     uint32, of the entries as little endian bytes
+//
+// followed by the deflated entries.
+// Each entry is stored as the difference to the previous entry,
+// which compresses much better for smooth tables.
+var magic = [8]byte{'G', 'A', 'D', 'L', 'U', 'T', '0', '1'}
+
+// samples is the number of entries compared to the formula when decoding.
+const samples = 256
+
+// ErrMismatch is returned when stored data doesn't match the table.
+var ErrMismatch = errors.New("lut: stored table does not match")
+
+// Encode writes the table entries in data to w.
+func (t Table) Encode(w io.Writer, data []uint32) error {
+	if len(data) != t.W*t.H {
+		return fmt.Errorf("lut: got %d entries, want %d", len(data), t.W*t.H)
+	}
+	raw := make([]byte, len(data)*4)
+	for i, v := range data {
+		binary.LittleEndian.PutUint32(raw[i*4:], v)
+	}
+	key := t.key()
+	hdr := make([]byte, 0, len(magic)+2+len(key)+8)
+	hdr = append(hdr, magic[:]...)
+	hdr = append(hdr, byte(len(key)), byte(len(key)>>8))
+	hdr = append(hdr, key...)
+	hdr = appendUint32(hdr, uint32(len(data)))
+	hdr = appendUint32(hdr, crc32.ChecksumIEEE(raw))
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+
+	// Replace entries with deltas.
+	var prev uint32
+	for i, v := range data {
+		binary.LittleEndian.PutUint32(raw[i*4:], v-prev)
+		prev = v
+	}
+	fw, err := flate.NewWriter(w, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(raw); err != nil {
+		return err
+	}
+	return fw.Close()
+}
+
+// Decode reads table entries written by Encode.
+// ErrMismatch is returned if the data is for another table or resolution,
+// or if some entries don't match the formula.
+func (t Table) Decode(r io.Reader) ([]uint32, error) {
+	br := bufio.NewReader(r)
+	var hdr [len(magic) + 2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hdr[:len(magic)], magic[:]) {
+		return nil, errors.New("lut: not a table")
+	}
+	key := make([]byte, int(hdr[len(magic)])|int(hdr[len(magic)+1])<<8)
+	if _, err := io.ReadFull(br, key); err != nil {
+		return nil, err
+	}
+	var tail [8]byte
+	if _, err := io.ReadFull(br, tail[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint32(tail[:])
+	crc := binary.LittleEndian.Uint32(tail[4:])
+	if string(key) != t.key() || int(n) != t.W*t.H {
+		return nil, ErrMismatch
+	}
+
+	raw, err := ioutil.ReadAll(flate.NewReader(br))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != int(n)*4 {
+		return nil, errors.New("lut: table truncated")
+	}
+	data := make([]uint32, n)
+	var prev uint32
+	for i := range data {
+		prev += binary.LittleEndian.Uint32(raw[i*4:])
+		data[i] = prev
+		binary.LittleEndian.PutUint32(raw[i*4:], prev)
+	}
+	if crc32.ChecksumIEEE(raw) != crc {
+		return nil, errors.New("lut: checksum mismatch")
+	}
+	if !t.check(data) {
+		return nil, ErrMismatch
+	}
+	return data, nil
+}
+
+// check compares entries spread over the table to the formula.
+func (t Table) check(data []uint32) bool {
+	if t.At == nil || len(data) == 0 {
+		return true
+	}
+	step := len(data) / samples
+	if step < 1 {
+		step = 1
+	}
+	// Offset by a prime, so samples don't line up in columns.
+	for i := 0; i < len(data); i += step + 7 {
+		if data[i] != t.At(i%t.W, i/t.W) {
+			return false
+		}
+	}
+	return true
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
@@ -0,0 +1,93 @@
+package lut
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testTable() Table {
+	return Table{
+		Name: "test",
+		W:    37,
+		H:    23,
+		At:   func(x, y int) uint32 { return uint32(x*x*7+y*1000) ^ uint32(y)<<24 },
+	}
+}
+
+func encode(t *testing.T, tab Table, data []uint32) []byte {
+	var buf bytes.Buffer
+	if err := tab.Encode(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	tab := testTable()
+	data := tab.Generate()
+	got, err := tab.Decode(bytes.NewReader(encode(t, tab, data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(data) {
+		t.Fatalf("got %d entries, want %d", len(got), len(data))
+	}
+	for i := range data {
+		if got[i] != data[i] {
+			t.Fatalf("entry %d: got %#x, want %#x", i, got[i], data[i])
+		}
+	}
+}
+
+func TestEncodeSize(t *testing.T) {
+	tab := testTable()
+	var buf bytes.Buffer
+	if err := tab.Encode(&buf, make([]uint32, 10)); err == nil {
+		t.Error("encoding the wrong number of entries should fail")
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	tab := testTable()
+	b := encode(t, tab, tab.Generate())
+
+	// The checksum is after the magic, key length, key and entry count.
+	crc := len(magic) + 2 + len(tab.key()) + 4
+	bad := append([]byte{}, b...)
+	bad[crc] ^= 1
+	if _, err := tab.Decode(bytes.NewReader(bad)); err == nil || err == ErrMismatch {
+		t.Errorf("corrupted checksum: got error %v, want checksum mismatch", err)
+	}
+
+	bad = append([]byte{}, b...)
+	bad[0] = 'X'
+	if _, err := tab.Decode(bytes.NewReader(bad)); err == nil {
+		t.Error("bad magic should fail")
+	}
+
+	if _, err := tab.Decode(bytes.NewReader(b[:len(b)/2])); err == nil {
+		t.Error("truncated data should fail")
+	}
+}
+
+func TestDecodeMismatch(t *testing.T) {
+	tab := testTable()
+	b := encode(t, tab, tab.Generate())
+
+	other := tab
+	other.Name = "other"
+	if _, err := other.Decode(bytes.NewReader(b)); err != ErrMismatch {
+		t.Errorf("other name: got %v, want ErrMismatch", err)
+	}
+	other = tab
+	other.W, other.H = tab.H, tab.W
+	if _, err := other.Decode(bytes.NewReader(b)); err != ErrMismatch {
+		t.Errorf("other size: got %v, want ErrMismatch", err)
+	}
+	// A changed formula with the same name is caught by the samples.
+	other = tab
+	other.At = func(x, y int) uint32 { return tab.At(x, y) + 1 }
+	if _, err := other.Decode(bytes.NewReader(b)); err != ErrMismatch {
+		t.Errorf("other formula: got %v, want ErrMismatch", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package lut stores precomputed lookup tables, so effects
+// don't have to calculate them on startup.
+//
+// A table is loaded from embedded data if present, then from a
+// cache on disk and is only calculated if neither matches.
+// Embedded tables are written at go generate time with lutgen and
+// embedded with go-bindata like other data.
+//
+// Stored tables contain the name and resolution of the table and
+// a sample of the entries is compared to the formula when loading,
+// so a stale table is never used.
+package lut
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/gfx"
+)
+
+// Table describes a lookup table with an entry for each pixel.
+type Table struct {
+	// Name of the table. Change it if the formula changes.
+	Name string
+	// Resolution of the table.
+	W, H int
+	// At calculates the entry for pixel x, y.
+	At func(x, y int) uint32
+}
+
+// key identifies the table in stored data.
+func (t Table) key() string {
+	return fmt.Sprintf("%s %dx%d", t.Name, t.W, t.H)
+}
+
+// File returns the file name of the table, for example "tunnel-8-640x360.lut".
+func (t Table) File() string {
+	return fmt.Sprintf("%s-%dx%d.lut", t.Name, t.W, t.H)
+}
+
+// Generate calculates all entries of the table, row by row.
+func (t Table) Generate() []uint32 {
+	data := make([]uint32, t.W*t.H)
+	for y := 0; y < t.H; y++ {
+		row := data[y*t.W : (y+1)*t.W]
+		for x := range row {
+			row[x] = t.At(x, y)
+		}
+	}
+	return data
+}
+
+// Load returns the entries of the table.
+// It is loaded from "data/" + t.File() using gfx.Load, then from the disk
+// cache and otherwise calculated and written to the disk cache.
+// Errors are ignored, since the table can always be calculated.
+func (t Table) Load() []uint32 {
+	if b, err := gfx.Load("data/" + t.File()); err == nil && len(b) > 0 {
+		if data, err := t.Decode(bytes.NewReader(b)); err == nil {
+			return data
+		}
+	}
+	cache := t.cacheFile()
+	if cache != "" {
+		if f, err := os.Open(cache); err == nil {
+			data, err := t.Decode(f)
+			f.Close()
+			if err == nil {
+				return data
+			}
+		}
+	}
+	data := t.Generate()
+	if cache != "" {
+		t.save(cache, data)
+	}
+	return data
+}
+
+// Lines returns the entries of a table as a slice per line.
+func (t Table) Lines(data []uint32) [][]uint32 {
+	lines := make([][]uint32, t.H)
+	for y := range lines {
+		lines[y] = data[y*t.W : (y+1)*t.W]
+	}
+	return lines
+}
+
+// cacheFile returns the disk cache file of the table,
+// or an empty string if there is no cache directory, like in the browser.
+func (t Table) cacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil || dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "gad-lut", t.File())
+}
+
+// save writes the table to a file, creating the directory if needed.
+func (t Table) save(file string, data []uint32) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file, so a partial file is never read.
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = t.Encode(f, data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,38 @@
+package lut
+
+import (
+	"fmt"
+	"math"
+)
+
+// Tunnel returns the lookup table used by tunnel effects.
+// Each entry has the distance in the lower 16 bits and the angle in the upper 16 bits,
+// both in the range 0 -> 65535.
+// Ratio is the number of texture repeats going into the tunnel
+// and angleScale the number of texture repeats around the tunnel.
+//
+// The center pixel has distance 0. It is infinitely far away,
+// which can't be converted to an integer in a portable way.
+func Tunnel(w, h int, ratio, angleScale float64) Table {
+	const prec = 1 << 16
+	return Table{
+		Name: fmt.Sprintf("tunnel-%g-%g", ratio, angleScale),
+		W:    w,
+		H:    h,
+		At: func(x, y int) uint32 {
+			// texture coordinates for screen space coordinates (x,y)
+			centerX, centerY := float64(x-w/2), float64(y-h/2)
+
+			// distance is u coordinate, 0 -> prec
+			var distance int
+			if dist := math.Sqrt(centerX*centerX + centerY*centerY); dist > 0 {
+				distance = int(ratio*prec/dist) % prec
+			}
+			// angle is v coordinate 0 -> prec
+			angle := int(angleScale*prec*math.Atan2(centerY, centerX)/math.Pi) % prec
+
+			// Store distance as lower 16 bits, angle as upper
+			return uint32(distance) | uint32(angle<<16)
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/klauspost/gad/hoaxplus/primitive"
+	"github.com/klauspost/gad/lissajous"
+	"github.com/klauspost/gad/metaballs"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+var balls = []metaballs.Ball{
+	{Radius: 40, Curve: lissajous.Curve{AmpX: 150, AmpY: 80, FreqX: 1, FreqY: 2}},
+	{Radius: 30, Curve: lissajous.Curve{AmpX: 120, AmpY: 100, FreqX: 2, FreqY: 1, Phase: 0.3}},
+	{Radius: 50, Curve: lissajous.Curve{AmpX: 60, AmpY: 40, FreqX: 3, FreqY: 2, Phase: 0.6}},
+	{Radius: 25, Curve: lissajous.Curve{X: 40, AmpX: 200, AmpY: 120, FreqX: 1, FreqY: 3, Phase: 0.1}},
+}
+
+func main() {
+	fx, err := metaballs.New(renderWidth, renderHeight, metaballs.Config{Balls: balls, Palette: metaballs.Palette})
+	// Solid balls:
+	//fx, err := metaballs.New(renderWidth, renderHeight, metaballs.Config{Balls: balls, Palette: metaballs.Palette, Threshold: 1})
+	if err != nil {
+		panic(err)
+	}
+	var effect gfx.TimedEffect = fx
+	if false {
+		// Marching cubes as wireframe.
+		gfx.InitShadedPalette(192, color.RGBA{R: 120, G: 200, B: 255})
+		effect = newWire()
+	}
+	gfx.Run(func() { gfx.RunTimed(effect) })
+}
+
+// wire draws 3D metaballs as a rotating wireframe.
+type wire struct {
+	draw         *image.Gray
+	balls        []metaballs.Ball
+	vTransformed primitive.P3Ds
+	vProjected   primitive.P2Ds
+}
+
+func newWire() *wire {
+	return &wire{
+		draw: image.NewGray(image.Rect(0, 0, renderWidth, renderHeight)),
+		balls: []metaballs.Ball{
+			{Radius: 1, Curve: lissajous.Curve{AmpX: 1, FreqX: 1}},
+			{Radius: 0.8, Curve: lissajous.Curve{AmpY: 1, FreqY: 1, Phase: 0.25}},
+			{Radius: 0.7, Curve: lissajous.Curve{AmpZ: 1, FreqZ: 1}},
+		},
+	}
+}
+
+// Render the effect at time t.
+func (fx *wire) Render(t float64) image.Image {
+	for i := range fx.draw.Pix {
+		fx.draw.Pix[i] = 0
+	}
+	m := metaballs.March(fx.balls, t, 1, primitive.Point3D{X: -3, Y: -3, Z: -3}, primitive.Point3D{X: 3, Y: 3, Z: 3}, 20)
+	if cap(fx.vTransformed) < len(m.Verts) {
+		fx.vTransformed = make(primitive.P3Ds, len(m.Verts))
+		fx.vProjected = make(primitive.P2Ds, len(m.Verts))
+	}
+	fx.vTransformed = fx.vTransformed[:len(m.Verts)]
+	fx.vProjected = fx.vProjected[:len(m.Verts)]
+
+	fw, fh := float32(renderWidth), float32(renderHeight)
+	m.Verts.RotateTo(fx.vTransformed, t*math.Pi*2, t*math.Pi*4, 0)
+	fx.vTransformed.ProjectTo(fx.vProjected, fw, fh, 6)
+	for _, edge := range m.Edges() {
+		p0, p1 := fx.vProjected[edge[0]], fx.vProjected[edge[1]]
+		if p0.X == primitive.BehindCamera || p1.X == primitive.BehindCamera {
+			continue
+		}
+		primitive.Line{P1: p0, P2: p1}.DrawAA(fx.draw, 192)
+	}
+	return fx.draw
+}
@@ -0,0 +1,182 @@
+package metaballs
+
+import (
+	"github.com/klauspost/gad/hoaxplus/primitive"
+)
+
+// Mesh is a triangle mesh.
+type Mesh struct {
+	Verts primitive.P3Ds
+	// Tris has the vertex indexes of each triangle.
+	// Seen from outside, vertices are in counter clockwise order
+	// in a right handed coordinate system.
+	Tris [][3]int
+}
+
+// Edges returns each edge of the triangles once,
+// so the mesh can be drawn as lines like a loaded OBJ.
+func (m Mesh) Edges() [][2]int {
+	seen := make(map[[2]int]struct{}, len(m.Tris)*3/2)
+	edges := make([][2]int, 0, len(m.Tris)*3/2)
+	for _, tri := range m.Tris {
+		for i := range tri {
+			a, b := tri[i], tri[(i+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			e := [2]int{a, b}
+			if _, ok := seen[e]; ok {
+				continue
+			}
+			seen[e] = struct{}{}
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// Field returns the sum of the fields of the balls at (x, y, z) at time t.
+func Field(balls []Ball, t, x, y, z float64) float64 {
+	var sum float64
+	for _, b := range balls {
+		bx, by, bz := b.At(t)
+		dx, dy, dz := x-bx, y-by, z-bz
+		r := b.Radius / Edge
+		d2 := dx*dx + dy*dy + dz*dz
+		if d2 >= r*r {
+			continue
+		}
+		f := 1 - d2/(r*r)
+		sum += f * f
+	}
+	return sum
+}
+
+// tets splits a cube into 6 tetrahedra around the diagonal from corner 0 to 7.
+// Corner bits are x, y and z.
+// Neighbor cubes split their shared faces the same way, so the surface has no holes.
+var tets = [6][4]int{
+	{0, 7, 1, 3},
+	{0, 7, 3, 2},
+	{0, 7, 2, 6},
+	{0, 7, 6, 4},
+	{0, 7, 4, 5},
+	{0, 7, 5, 1},
+}
+
+// March returns the surface of the balls at time t as triangles.
+// The threshold is relative to the edge of a lone ball like Config.Threshold.
+//
+// The box from min to max is divided into n cubes on each axis.
+// This is marching cubes, but with each cube split into tetrahedra,
+// which only have 16 cases and never give ambiguous surfaces.
+func March(balls []Ball, t, threshold float64, min, max primitive.Point3D, n int) Mesh {
+	if n < 1 {
+		return Mesh{}
+	}
+	level := threshold * 0.5
+	p := n + 1
+	step := [3]float64{
+		float64(max.X-min.X) / float64(n),
+		float64(max.Y-min.Y) / float64(n),
+		float64(max.Z-min.Z) / float64(n),
+	}
+	pos := func(i int) [3]float64 {
+		x, y, z := i%p, (i/p)%p, i/(p*p)
+		return [3]float64{
+			float64(min.X) + float64(x)*step[0],
+			float64(min.Y) + float64(y)*step[1],
+			float64(min.Z) + float64(z)*step[2],
+		}
+	}
+
+	// Field minus level at each grid point, so the surface is at 0.
+	values := make([]float64, p*p*p)
+	for i := range values {
+		c := pos(i)
+		values[i] = Field(balls, t, c[0], c[1], c[2]) - level
+	}
+
+	var m Mesh
+	// Vertices are shared between triangles, keyed by their grid edge.
+	verts := make(map[[2]int]int)
+	vertex := func(a, b int) int {
+		if a > b {
+			a, b = b, a
+		}
+		key := [2]int{a, b}
+		if v, ok := verts[key]; ok {
+			return v
+		}
+		pa, pb := pos(a), pos(b)
+		f := values[a] / (values[a] - values[b])
+		v := len(m.Verts)
+		m.Verts = append(m.Verts, primitive.Point3D{
+			X: float32(pa[0] + (pb[0]-pa[0])*f),
+			Y: float32(pa[1] + (pb[1]-pa[1])*f),
+			Z: float32(pa[2] + (pb[2]-pa[2])*f),
+		})
+		verts[key] = v
+		return v
+	}
+	// det returns the signed volume of grid points o, a, b and c, times 6.
+	det := func(o, a, b, c int) float64 {
+		po, pa, pb, pc := pos(o), pos(a), pos(b), pos(c)
+		for i := range po {
+			pa[i] -= po[i]
+			pb[i] -= po[i]
+			pc[i] -= po[i]
+		}
+		return pa[0]*(pb[1]*pc[2]-pb[2]*pc[1]) + pa[1]*(pb[2]*pc[0]-pb[0]*pc[2]) + pa[2]*(pb[0]*pc[1]-pb[1]*pc[0])
+	}
+	// triangle adds a triangle, reversed if it isn't facing out.
+	// The direction is found from the grid points of the tetrahedron,
+	// since triangles with vertices on grid points can have no area.
+	triangle := func(a, b, c int, facing bool) {
+		if !facing {
+			b, c = c, b
+		}
+		m.Tris = append(m.Tris, [3]int{a, b, c})
+	}
+
+	for z := 0; z < n; z++ {
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				var corner [8]int
+				for i := range corner {
+					corner[i] = (x + i&1) + (y+(i>>1)&1)*p + (z+(i>>2)&1)*p*p
+				}
+				for _, tet := range tets {
+					var in, out [4]int
+					nIn, nOut := 0, 0
+					for _, c := range tet {
+						g := corner[c]
+						if values[g] > 0 {
+							in[nIn] = g
+							nIn++
+						} else {
+							out[nOut] = g
+							nOut++
+						}
+					}
+					switch nIn {
+					case 1:
+						facing := det(in[0], out[0], out[1], out[2]) > 0
+						triangle(vertex(in[0], out[0]), vertex(in[0], out[1]), vertex(in[0], out[2]), facing)
+					case 3:
+						facing := det(out[0], in[0], in[1], in[2]) < 0
+						triangle(vertex(out[0], in[0]), vertex(out[0], in[1]), vertex(out[0], in[2]), facing)
+					case 2:
+						// The edges form a quad, split in two triangles.
+						a, b := vertex(in[0], out[0]), vertex(in[0], out[1])
+						c, d := vertex(in[1], out[1]), vertex(in[1], out[0])
+						facing := det(in[0], out[0], out[1], in[1]) > 0
+						triangle(a, b, c, facing)
+						triangle(a, c, d, facing)
+					}
+				}
+			}
+		}
+	}
+	return m
+}
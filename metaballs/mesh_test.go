@@ -0,0 +1,98 @@
+package metaballs
+
+import (
+	"math"
+	"testing"
+
+	"github.com/klauspost/gad/hoaxplus/primitive"
+	"github.com/klauspost/gad/lissajous"
+)
+
+// checkClosed checks that every edge of m is used by exactly two triangles,
+// in opposite directions, and returns the Euler characteristic V - E + F.
+func checkClosed(t *testing.T, m Mesh) int {
+	directed := make(map[[2]int]int)
+	for _, tri := range m.Tris {
+		for i := range tri {
+			directed[[2]int{tri[i], tri[(i+1)%3]}]++
+		}
+	}
+	for e, n := range directed {
+		if n != 1 {
+			t.Fatalf("edge %v is used %d times in the same direction", e, n)
+		}
+		if directed[[2]int{e[1], e[0]}] != 1 {
+			t.Fatalf("edge %v has no opposite edge", e)
+		}
+	}
+	edges := m.Edges()
+	if len(edges)*2 != len(directed) {
+		t.Fatalf("got %d edges, want %d", len(edges), len(directed)/2)
+	}
+	return len(m.Verts) - len(edges) + len(m.Tris)
+}
+
+// volume returns the signed volume of m.
+// It is positive if the triangles face outwards.
+func volume(m Mesh) float64 {
+	var v float64
+	for _, tri := range m.Tris {
+		a, b, c := m.Verts[tri[0]], m.Verts[tri[1]], m.Verts[tri[2]]
+		v += float64(a.X*(b.Y*c.Z-b.Z*c.Y) + a.Y*(b.Z*c.X-b.X*c.Z) + a.Z*(b.X*c.Y-b.Y*c.X))
+	}
+	return v / 6
+}
+
+func TestMarchSphere(t *testing.T) {
+	balls := []Ball{{Radius: 1}}
+	min, max := primitive.Point3D{X: -2, Y: -2, Z: -2}, primitive.Point3D{X: 2, Y: 2, Z: 2}
+	for _, n := range []int{7, 12, 16} {
+		m := March(balls, 0, 1, min, max, n)
+		if len(m.Tris) == 0 {
+			t.Fatalf("n=%d: no triangles", n)
+		}
+		if chi := checkClosed(t, m); chi != 2 {
+			t.Errorf("n=%d: V-E+F = %d, want 2", n, chi)
+		}
+		// Vertices are on the radius, within the grid resolution.
+		cell := 4 / float64(n)
+		for i, v := range m.Verts {
+			d := math.Sqrt(float64(v.X*v.X + v.Y*v.Y + v.Z*v.Z))
+			if math.Abs(d-1) > cell/2 {
+				t.Fatalf("n=%d: vertex %d at distance %v", n, i, d)
+			}
+		}
+		want := 4.0 / 3 * math.Pi
+		if v := volume(m); v < want*0.7 || v > want*1.05 {
+			t.Errorf("n=%d: volume %v, want about %v", n, v, want)
+		}
+	}
+}
+
+func TestMarchTwoBalls(t *testing.T) {
+	// Far apart balls give two spheres, close balls merge into one.
+	min, max := primitive.Point3D{X: -5, Y: -2, Z: -2}, primitive.Point3D{X: 5, Y: 2, Z: 2}
+	for _, v := range []struct {
+		x    float64
+		want int
+	}{{2.5, 4}, {0.8, 2}} {
+		balls := []Ball{
+			{Radius: 1, Curve: lissajous.Curve{X: -v.x}},
+			{Radius: 1, Curve: lissajous.Curve{X: v.x}},
+		}
+		m := March(balls, 0, 1, min, max, 30)
+		if chi := checkClosed(t, m); chi != v.want {
+			t.Errorf("balls at +-%v: V-E+F = %d, want %d", v.x, chi, v.want)
+		}
+	}
+}
+
+func TestMarchEmpty(t *testing.T) {
+	min, max := primitive.Point3D{X: -2, Y: -2, Z: -2}, primitive.Point3D{X: 2, Y: 2, Z: 2}
+	if m := March(nil, 0, 1, min, max, 8); len(m.Tris) != 0 || len(m.Verts) != 0 {
+		t.Errorf("no balls: got %d triangles", len(m.Tris))
+	}
+	if m := March([]Ball{{Radius: 1}}, 0, 1, min, max, 0); len(m.Tris) != 0 {
+		t.Errorf("n=0: got %d triangles", len(m.Tris))
+	}
+}
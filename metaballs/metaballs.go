@@ -0,0 +1,210 @@
+// Package metaballs renders blobs that melt together when they get close.
+//
+// Each ball adds a field that falls off with the distance and
+// is zero outside its influence radius. The sum of the fields
+// is either thresholded or shaded through a palette.
+//
+// In 2D the field of each ball is a precomputed table, which is
+// added to the pixels around the ball, so no distances are
+// calculated while rendering.
+// In 3D the surface is turned into triangles, see Mesh.
+package metaballs
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/klauspost/gad/lissajous"
+	"github.com/klauspost/gad/texture"
+)
+
+// Ball is a single moving ball.
+// In 2D the center of its curve is relative to the screen center.
+type Ball struct {
+	// Radius of the ball when it is alone.
+	Radius float64
+
+	lissajous.Curve
+}
+
+// Falloff is the field of a ball at distance d, 0 -> 1.
+// The field is 1 at the center, 0.5 at the radius and
+// 0 at the influence radius of Radius / Edge and beyond.
+func (b Ball) Falloff(d float64) float64 {
+	r := b.Radius / Edge
+	if d >= r {
+		return 0
+	}
+	f := 1 - d*d/(r*r)
+	return f * f
+}
+
+// Edge is the fraction of the influence radius where the field of a lone ball is 0.5.
+var Edge = math.Sqrt(1 - math.Sqrt(0.5))
+
+// Palette is a blue palette for shaded metaballs.
+var Palette = texture.NewPalette(false,
+	color.RGBA{A: 255},
+	color.RGBA{G: 20, B: 90, A: 255},
+	color.RGBA{G: 120, B: 220, A: 255},
+	color.RGBA{R: 240, G: 250, B: 255, A: 255},
+)
+
+// Config describes a metaballs effect.
+type Config struct {
+	Balls []Ball
+
+	// Palette is used for the output.
+	Palette color.Palette
+
+	// Threshold gives solid balls, using the last palette entry inside and the first outside.
+	// The value is relative to the edge of a lone ball, so 1 gives balls of their radius.
+	// If 0, the field is shaded, with palette index 128 at the edge of a lone ball.
+	Threshold float64
+}
+
+// one is the field value at the edge of a lone ball.
+const one = 256
+
+// table has the precomputed field of a ball.
+type table struct {
+	// Field values, (2 * size + 1)^2 with the ball in the center.
+	field []uint16
+	size  int
+}
+
+// Fx is a 2D metaballs effect.
+type Fx struct {
+	cfg    Config
+	tables []table
+	// field has the sum for each pixel.
+	field []uint16
+	// shade converts field to palette index.
+	shade []uint8
+	draw  *image.Paletted
+	lines [][]byte
+	w, h  int
+}
+
+// New returns a metaballs effect rendering w * h pixels.
+func New(w, h int, c Config) (*Fx, error) {
+	if len(c.Balls) == 0 {
+		return nil, errors.New("metaballs: no balls")
+	}
+	if len(c.Palette) == 0 {
+		return nil, errors.New("metaballs: empty palette")
+	}
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("metaballs: invalid size")
+	}
+	fx := Fx{cfg: c, w: w, h: h}
+
+	// Calculate the field around each ball, so rendering is only additions.
+	total := 0
+	for _, b := range c.Balls {
+		if b.Radius <= 0 {
+			return nil, errors.New("metaballs: ball radius must be positive")
+		}
+		size := int(math.Ceil(b.Radius / Edge))
+		tab := table{size: size, field: make([]uint16, (2*size+1)*(2*size+1))}
+		for y := -size; y <= size; y++ {
+			row := tab.field[(y+size)*(2*size+1):]
+			for x := -size; x <= size; x++ {
+				d := math.Sqrt(float64(x*x + y*y))
+				row[x+size] = uint16(b.Falloff(d)*2*one + 0.5)
+			}
+		}
+		fx.tables = append(fx.tables, tab)
+		total += 2 * one
+	}
+	if total > math.MaxUint16 {
+		return nil, errors.New("metaballs: too many balls")
+	}
+
+	// Table from field value to palette index.
+	fx.shade = make([]uint8, total+1)
+	last := uint8(len(c.Palette) - 1)
+	if len(c.Palette) > 256 {
+		last = 255
+	}
+	thr := int(c.Threshold * one)
+	for f := range fx.shade {
+		switch {
+		case c.Threshold > 0 && f >= thr:
+			fx.shade[f] = last
+		case c.Threshold > 0:
+			fx.shade[f] = 0
+		default:
+			v := f * 128 / one
+			if v > 255 {
+				v = 255
+			}
+			fx.shade[f] = uint8(v * int(last) / 255)
+		}
+	}
+
+	fx.field = make([]uint16, w*h)
+	fx.draw = image.NewPaletted(image.Rect(0, 0, w, h), c.Palette)
+
+	// Store each line as a slice in a slice.
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+	return &fx, nil
+}
+
+// Render the effect at time t.
+func (fx *Fx) Render(t float64) image.Image {
+	for i := range fx.field {
+		fx.field[i] = 0
+	}
+	for i, b := range fx.cfg.Balls {
+		bx, by, _ := b.At(t)
+		fx.add(fx.tables[i], fx.w/2+int(math.Floor(bx+0.5)), fx.h/2+int(math.Floor(by+0.5)))
+	}
+	shade := fx.shade
+	for y, line := range fx.lines {
+		field := fx.field[y*fx.w : (y+1)*fx.w]
+		for x, f := range field {
+			line[x] = shade[f]
+		}
+	}
+	return fx.draw
+}
+
+// add the field of a ball centered at (cx, cy).
+func (fx *Fx) add(tab table, cx, cy int) {
+	size := tab.size
+	stride := 2*size + 1
+	// Clip the box of the ball to the screen.
+	x0, x1 := cx-size, cx+size+1
+	y0, y1 := cy-size, cy+size+1
+	tx, ty := 0, 0
+	if x0 < 0 {
+		tx = -x0
+		x0 = 0
+	}
+	if y0 < 0 {
+		ty = -y0
+		y0 = 0
+	}
+	if x1 > fx.w {
+		x1 = fx.w
+	}
+	if y1 > fx.h {
+		y1 = fx.h
+	}
+	if x0 >= x1 || y0 >= y1 {
+		return
+	}
+	for y := y0; y < y1; y++ {
+		src := tab.field[(ty+y-y0)*stride+tx:]
+		dst := fx.field[y*fx.w+x0 : y*fx.w+x1]
+		for x := range dst {
+			dst[x] += src[x]
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package mipmap
+
+import "math"
+
+// Filter is a reconstruction filter used when downsampling.
+type Filter struct {
+	// Support is the radius of the filter in source pixels at scale 1.
+	Support float64
+	// Kernel returns the weight at distance x.
+	Kernel func(x float64) float64
+}
+
+var (
+	// Box averages the pixels covered by the destination pixel.
+	// When halving the size this is a plain 2x2 average.
+	Box = Filter{Support: 0.5, Kernel: func(x float64) float64 {
+		if x >= -0.5 && x < 0.5 {
+			return 1
+		}
+		return 0
+	}}
+
+	// Tent is a linear (triangle) filter.
+	// It is slightly softer than Box, but has fewer artifacts.
+	Tent = Filter{Support: 1, Kernel: func(x float64) float64 {
+		x = math.Abs(x)
+		if x < 1 {
+			return 1 - x
+		}
+		return 0
+	}}
+
+	// Lanczos is a 3-lobed Lanczos filter.
+	// It keeps the most detail, but may ring at hard edges.
+	Lanczos = Filter{Support: 3, Kernel: func(x float64) float64 {
+		x = math.Abs(x)
+		if x == 0 {
+			return 1
+		}
+		if x >= 3 {
+			return 0
+		}
+		x *= math.Pi
+		return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+	}}
+)
+
+// contrib is the weight of a single source pixel.
+type contrib struct {
+	idx    int
+	weight float32
+}
+
+// weights returns the source pixels and their weights
+// for each of the dstN pixels when resampling from srcN pixels.
+// Pixels outside the source are clamped to the edge.
+func (f Filter) weights(srcN, dstN int) [][]contrib {
+	scale := float64(srcN) / float64(dstN)
+	// When downsampling the filter is stretched to cover all source pixels.
+	fscale := math.Max(scale, 1)
+	support := f.Support * fscale
+
+	res := make([][]contrib, dstN)
+	for i := range res {
+		// Center of destination pixel in source space.
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Floor(center - support))
+		end := int(math.Ceil(center + support))
+		var total float64
+		var c []contrib
+		for j := start; j <= end; j++ {
+			w := f.Kernel((float64(j) - center) / fscale)
+			if w == 0 {
+				continue
+			}
+			idx := j
+			if idx < 0 {
+				idx = 0
+			}
+			if idx >= srcN {
+				idx = srcN - 1
+			}
+			c = append(c, contrib{idx: idx, weight: float32(w)})
+			total += w
+		}
+		// Normalize, so weights add up to 1.
+		if total != 0 {
+			for k := range c {
+				c[k].weight /= float32(total)
+			}
+		}
+		res[i] = c
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+// Package mipmap creates mipmap chains.
+//
+// Each level in a chain is half the width and height of the previous,
+// rounded down, until the last level is 1x1 pixel.
+// Level 0 is always the source image.
+// Sources do not need to be square or a power of two.
+package mipmap
+
+import (
+	"image"
+	"image/color"
+)
+
+// Gray returns a mipmap chain of img using filter f.
+func Gray(img *image.Gray, f Filter) []*image.Gray {
+	chain := []*image.Gray{img}
+	prev := img
+	for prev.Rect.Dx() > 1 || prev.Rect.Dy() > 1 {
+		prev = HalfGray(prev, f)
+		chain = append(chain, prev)
+	}
+	return chain
+}
+
+// HalfGray returns img downsampled to half size using filter f.
+func HalfGray(img *image.Gray, f Filter) *image.Gray {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := half(w), half(h)
+	return &image.Gray{
+		Pix:    resample(img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):], w, h, img.Stride, 1, dw, dh, f),
+		Stride: dw,
+		Rect:   image.Rect(0, 0, dw, dh),
+	}
+}
+
+// RGBA returns a mipmap chain of img using filter f.
+// Since pixels are premultiplied, transparent pixels will not bleed color.
+func RGBA(img *image.RGBA, f Filter) []*image.RGBA {
+	chain := []*image.RGBA{img}
+	prev := img
+	for prev.Rect.Dx() > 1 || prev.Rect.Dy() > 1 {
+		prev = HalfRGBA(prev, f)
+		chain = append(chain, prev)
+	}
+	return chain
+}
+
+// HalfRGBA returns img downsampled to half size using filter f.
+func HalfRGBA(img *image.RGBA, f Filter) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := half(w), half(h)
+	return &image.RGBA{
+		Pix:    resample(img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):], w, h, img.Stride, 4, dw, dh, f),
+		Stride: dw * 4,
+		Rect:   image.Rect(0, 0, dw, dh),
+	}
+}
+
+// Paletted returns a mipmap chain of img using filter f.
+// Each level is filtered in RGBA and mapped back to the closest color in the palette.
+// All levels share the palette of img.
+func Paletted(img *image.Paletted, f Filter) []*image.Paletted {
+	chain := []*image.Paletted{img}
+	if img.Rect.Dx() <= 1 && img.Rect.Dy() <= 1 {
+		return chain
+	}
+	lu := newInverse(img.Palette)
+	// Filter each level from the RGBA version of the previous
+	// to avoid accumulating quantization errors.
+	prev := palToRGBA(img)
+	for prev.Rect.Dx() > 1 || prev.Rect.Dy() > 1 {
+		prev = HalfRGBA(prev, f)
+		chain = append(chain, lu.quantize(prev))
+	}
+	return chain
+}
+
+// palToRGBA converts a paletted image to RGBA using palette lookup.
+func palToRGBA(img *image.Paletted) *image.RGBA {
+	var pal [256]color.RGBA
+	for i, c := range img.Palette {
+		pal[i] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		src := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):][:w]
+		line := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+		for x, idx := range src {
+			c := pal[idx]
+			line[x*4] = c.R
+			line[x*4+1] = c.G
+			line[x*4+2] = c.B
+			line[x*4+3] = c.A
+		}
+	}
+	return dst
+}
+
+// inverse maps colors to the closest palette index.
+// Colors are looked up with 5 bits per channel and cached.
+type inverse struct {
+	pal   color.Palette
+	cache map[uint32]uint8
+}
+
+func newInverse(pal color.Palette) *inverse {
+	return &inverse{pal: pal, cache: make(map[uint32]uint8)}
+}
+
+func (iv *inverse) quantize(img *image.RGBA) *image.Paletted {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dst := image.NewPaletted(image.Rect(0, 0, w, h), iv.pal)
+	for y := 0; y < h; y++ {
+		src := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		line := dst.Pix[y*dst.Stride : y*dst.Stride+w]
+		for x := range line {
+			p := src[x*4 : x*4+4 : x*4+4]
+			key := uint32(p[0]>>3) | uint32(p[1]>>3)<<5 | uint32(p[2]>>3)<<10 | uint32(p[3]>>3)<<15
+			idx, ok := iv.cache[key]
+			if !ok {
+				idx = uint8(iv.pal.Index(color.RGBA{R: p[0], G: p[1], B: p[2], A: p[3]}))
+				iv.cache[key] = idx
+			}
+			line[x] = idx
+		}
+	}
+	return dst
+}
@@ -0,0 +1,205 @@
+package mipmap
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// grayOf returns a w * h image with pixels from fn.
+func grayOf(w, h int, fn func(x, y int) uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Pix[y*img.Stride+x] = fn(x, y)
+		}
+	}
+	return img
+}
+
+func TestHalfGrayBox(t *testing.T) {
+	// Each output pixel is the average of a 2x2 block:
+	// (0+10+40+50)/4, (20+30+60+70)/4, (80+90+120+130)/4, (100+110+140+150)/4.
+	img := grayOf(4, 4, func(x, y int) uint8 { return uint8(x*10 + y*40) })
+	got := HalfGray(img, Box)
+	want := []uint8{25, 45, 105, 125}
+	if got.Rect.Dx() != 2 || got.Rect.Dy() != 2 {
+		t.Fatalf("got size %v, want 2x2", got.Rect)
+	}
+	for i, v := range want {
+		if got.Pix[i] != v {
+			t.Errorf("pixel %d: got %d, want %d", i, got.Pix[i], v)
+		}
+	}
+}
+
+func TestHalfGrayBoxOddColumn(t *testing.T) {
+	// A single pixel in an odd column must only be counted once.
+	for _, x0 := range []int{0, 1, 2, 3} {
+		img := grayOf(4, 2, func(x, y int) uint8 {
+			if x == x0 && y == 0 {
+				return 200
+			}
+			return 0
+		})
+		got := HalfGray(img, Box)
+		for x := 0; x < 2; x++ {
+			want := uint8(0)
+			if x == x0/2 {
+				want = 50
+			}
+			if got.Pix[x] != want {
+				t.Errorf("pixel at %d: output %d got %d, want %d", x0, x, got.Pix[x], want)
+			}
+		}
+	}
+}
+
+func TestGrayChainSizes(t *testing.T) {
+	tests := []struct {
+		w, h  int
+		sizes [][2]int
+	}{
+		{w: 5, h: 3, sizes: [][2]int{{5, 3}, {2, 1}, {1, 1}}},
+		{w: 1, h: 8, sizes: [][2]int{{1, 8}, {1, 4}, {1, 2}, {1, 1}}},
+		{w: 1, h: 7, sizes: [][2]int{{1, 7}, {1, 3}, {1, 1}}},
+		{w: 1, h: 1, sizes: [][2]int{{1, 1}}},
+		{w: 16, h: 4, sizes: [][2]int{{16, 4}, {8, 2}, {4, 1}, {2, 1}, {1, 1}}},
+	}
+	for _, test := range tests {
+		chain := Gray(grayOf(test.w, test.h, func(x, y int) uint8 { return uint8(x + y) }), Box)
+		if len(chain) != len(test.sizes) {
+			t.Errorf("%dx%d: got %d levels, want %d", test.w, test.h, len(chain), len(test.sizes))
+			continue
+		}
+		for i, s := range test.sizes {
+			if chain[i].Rect.Dx() != s[0] || chain[i].Rect.Dy() != s[1] {
+				t.Errorf("%dx%d level %d: got %v, want %dx%d", test.w, test.h, i, chain[i].Rect, s[0], s[1])
+			}
+		}
+	}
+}
+
+func TestHalfGrayOdd(t *testing.T) {
+	// 5x3 -> 2x1: the first output covers columns 0-1, the second columns 2-4,
+	// and both cover all rows.
+	img := grayOf(5, 3, func(x, y int) uint8 { return uint8(x*30 + y*3) })
+	got := HalfGray(img, Box)
+	want := []uint8{18, 93}
+	for i, v := range want {
+		if got.Pix[i] != v {
+			t.Errorf("pixel %d: got %d, want %d", i, got.Pix[i], v)
+		}
+	}
+}
+
+// reference resamples src from w * h to dw * dh in float64,
+// weighting each source pixel with the 2D filter directly.
+func reference(src []uint8, w, h, dw, dh int, f Filter) []float64 {
+	sx, sy := float64(w)/float64(dw), float64(h)/float64(dh)
+	fx, fy := math.Max(sx, 1), math.Max(sy, 1)
+	clamp := func(v, n int) int {
+		if v < 0 {
+			return 0
+		}
+		if v >= n {
+			return n - 1
+		}
+		return v
+	}
+	out := make([]float64, dw*dh)
+	for y := 0; y < dh; y++ {
+		cy := (float64(y)+0.5)*sy - 0.5
+		for x := 0; x < dw; x++ {
+			cx := (float64(x)+0.5)*sx - 0.5
+			var sum, total float64
+			for j := int(math.Floor(cy - f.Support*fy)); j <= int(math.Ceil(cy+f.Support*fy)); j++ {
+				wy := f.Kernel((float64(j) - cy) / fy)
+				for i := int(math.Floor(cx - f.Support*fx)); i <= int(math.Ceil(cx+f.Support*fx)); i++ {
+					wgt := wy * f.Kernel((float64(i)-cx)/fx)
+					sum += wgt * float64(src[clamp(j, h)*w+clamp(i, w)])
+					total += wgt
+				}
+			}
+			out[y*dw+x] = math.Max(0, math.Min(255, sum/total))
+		}
+	}
+	return out
+}
+
+func TestFiltersMatchReference(t *testing.T) {
+	sizes := [][2]int{{16, 16}, {17, 9}, {5, 3}, {1, 12}, {12, 1}}
+	filters := map[string]Filter{"box": Box, "tent": Tent, "lanczos": Lanczos}
+	for name, f := range filters {
+		for _, s := range sizes {
+			w, h := s[0], s[1]
+			// Hard edges and a gradient, so negative lobes are exercised.
+			img := grayOf(w, h, func(x, y int) uint8 {
+				if (x/3+y/2)%2 == 0 {
+					return uint8(255 - x*7)
+				}
+				return uint8(y * 11)
+			})
+			got := HalfGray(img, f)
+			want := reference(img.Pix, w, h, half(w), half(h), f)
+			for i, v := range want {
+				if d := math.Abs(float64(got.Pix[i]) - v); d > 1 {
+					t.Errorf("%s %dx%d pixel %d: got %d, want %.2f", name, w, h, i, got.Pix[i], v)
+				}
+			}
+		}
+	}
+}
+
+func TestHalfRGBAChannels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := 0; i < 4; i++ {
+		copy(img.Pix[i*4:], []uint8{uint8(i * 40), 100, uint8(200 - i*40), 255})
+	}
+	got := HalfRGBA(img, Box)
+	want := []uint8{60, 100, 140, 255}
+	for i, v := range want {
+		if got.Pix[i] != v {
+			t.Errorf("channel %d: got %d, want %d", i, got.Pix[i], v)
+		}
+	}
+}
+
+func TestPaletted(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{A: 255},
+		color.RGBA{R: 255, A: 255},
+		color.RGBA{G: 255, A: 255},
+		color.RGBA{B: 255, A: 255},
+		color.RGBA{R: 255, G: 255, B: 255, A: 255},
+	}
+	img := image.NewPaletted(image.Rect(0, 0, 16, 8), pal)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			// Blocks of 4x4 of a single color, so early levels are exact.
+			img.Pix[y*img.Stride+x] = uint8((x/4 + y/4) % len(pal))
+		}
+	}
+	chain := Paletted(img, Box)
+	if len(chain) != 5 {
+		t.Fatalf("got %d levels, want 5", len(chain))
+	}
+	for i, level := range chain {
+		for _, idx := range level.Pix {
+			if int(idx) >= len(pal) {
+				t.Fatalf("level %d: index %d outside palette", i, idx)
+			}
+		}
+	}
+	// Level 2 is 4x2 with one pixel per block, which is exactly a palette color.
+	l2 := chain[2]
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			want := uint8((x + y) % len(pal))
+			if got := l2.Pix[y*l2.Stride+x]; got != want {
+				t.Errorf("level 2 (%d,%d): got index %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package mipmap
+
+// resample will resample interleaved 8 bit pixels with ch channels
+// from w * h to dw * dh pixels.
+// The returned pixels are packed with a stride of dw * ch.
+func resample(src []byte, w, h, stride, ch, dw, dh int, f Filter) []byte {
+	wx := f.weights(w, dw)
+	wy := f.weights(h, dh)
+
+	// Horizontal pass into floats.
+	tmp := make([]float32, dw*h*ch)
+	for y := 0; y < h; y++ {
+		line := src[y*stride:]
+		dst := tmp[y*dw*ch:]
+		for x, cs := range wx {
+			for c := 0; c < ch; c++ {
+				var v float32
+				for _, con := range cs {
+					v += float32(line[con.idx*ch+c]) * con.weight
+				}
+				dst[x*ch+c] = v
+			}
+		}
+	}
+
+	// Vertical pass, rounded and clamped.
+	out := make([]byte, dw*dh*ch)
+	lineLen := dw * ch
+	for y, cs := range wy {
+		dst := out[y*lineLen : (y+1)*lineLen]
+		for i := range dst {
+			var v float32
+			for _, con := range cs {
+				v += tmp[con.idx*lineLen+i] * con.weight
+			}
+			dst[i] = clampf(v)
+		}
+	}
+	return out
+}
+
+func clampf(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// half returns half of n, but at least 1.
+func half(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n / 2
+}
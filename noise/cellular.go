@@ -0,0 +1,58 @@
+package noise
+
+import "math"
+
+// Cellular (Worley) noise places one random feature point in each unit cell
+// and returns the distances to the closest ones.
+// F1 gives round cells, F2-F1 gives cracks between cells.
+
+// Cellular2 returns the distance to the closest (f1)
+// and second closest (f2) feature point from (x, y).
+func (n *Noise) Cellular2(x, y float64) (f1, f2 float64) {
+	return n.Cellular2Tile(x, y, 256, 256)
+}
+
+// Cellular2Tile returns cellular noise that repeats every px, py cells.
+// Periods must be at least 1.
+func (n *Noise) Cellular2Tile(x, y float64, px, py int) (f1, f2 float64) {
+	ix, iy := floor(x), floor(y)
+	d1, d2 := math.MaxFloat64, math.MaxFloat64
+	for cy := iy - 1; cy <= iy+1; cy++ {
+		for cx := ix - 1; cx <= ix+1; cx++ {
+			h := n.hash2(mod(cx, px), mod(cy, py))
+			dx := float64(cx) + float64(h)/256 - x
+			dy := float64(cy) + float64(n.perm[h+1])/256 - y
+			d := dx*dx + dy*dy
+			if d < d1 {
+				d1, d2 = d, d1
+			} else if d < d2 {
+				d2 = d
+			}
+		}
+	}
+	return math.Sqrt(d1), math.Sqrt(d2)
+}
+
+// Cellular3 returns the distance to the closest (f1)
+// and second closest (f2) feature point from (x, y, z).
+func (n *Noise) Cellular3(x, y, z float64) (f1, f2 float64) {
+	ix, iy, iz := floor(x), floor(y), floor(z)
+	d1, d2 := math.MaxFloat64, math.MaxFloat64
+	for cz := iz - 1; cz <= iz+1; cz++ {
+		for cy := iy - 1; cy <= iy+1; cy++ {
+			for cx := ix - 1; cx <= ix+1; cx++ {
+				h := n.hash3(cx, cy, cz)
+				dx := float64(cx) + float64(h)/256 - x
+				dy := float64(cy) + float64(n.perm[h+1])/256 - y
+				dz := float64(cz) + float64(n.perm[h+2])/256 - z
+				d := dx*dx + dy*dy + dz*dz
+				if d < d1 {
+					d1, d2 = d, d1
+				} else if d < d2 {
+					d2 = d
+				}
+			}
+		}
+	}
+	return math.Sqrt(d1), math.Sqrt(d2)
+}
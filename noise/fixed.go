@@ -0,0 +1,103 @@
+package noise
+
+// Fixed point Perlin noise for inner loops.
+// Coordinates and results are 16.16 fixed point.
+// Results are in the range -65536 -> 65536 and match the float
+// versions within rounding.
+
+const (
+	fixedShift = 16
+	fixedOne   = 1 << fixedShift
+	fixedMask  = fixedOne - 1
+)
+
+// fadeFixed is fade for t in 0 -> 65536.
+func fadeFixed(t int64) int64 {
+	t3 := (t * t >> fixedShift) * t >> fixedShift
+	return t3 * ((t*(t*6-15*fixedOne))>>fixedShift + 10*fixedOne) >> fixedShift
+}
+
+func lerpFixed(a, b, t int64) int64 {
+	return a + (b-a)*t>>fixedShift
+}
+
+func grad2Fixed(h int, x, y int64) int64 {
+	switch h & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return x - y
+	case 2:
+		return -x + y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+func grad3Fixed(h int, x, y, z int64) int64 {
+	switch h & 15 {
+	case 0, 12:
+		return x + y
+	case 1, 13:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x + z
+	case 5:
+		return -x + z
+	case 6:
+		return x - z
+	case 7:
+		return -x - z
+	case 8:
+		return y + z
+	case 9, 14:
+		return -y + z
+	case 10:
+		return y - z
+	default:
+		return -y - z
+	}
+}
+
+// Perlin2Fixed returns 2D Perlin noise at (x, y).
+func (n *Noise) Perlin2Fixed(x, y int32) int32 {
+	ix, iy := int(x>>fixedShift), int(y>>fixedShift)
+	fx, fy := int64(x&fixedMask), int64(y&fixedMask)
+	u, v := fadeFixed(fx), fadeFixed(fy)
+
+	a := lerpFixed(grad2Fixed(n.hash2(ix, iy), fx, fy), grad2Fixed(n.hash2(ix+1, iy), fx-fixedOne, fy), u)
+	b := lerpFixed(grad2Fixed(n.hash2(ix, iy+1), fx, fy-fixedOne), grad2Fixed(n.hash2(ix+1, iy+1), fx-fixedOne, fy-fixedOne), u)
+	return int32(lerpFixed(a, b, v))
+}
+
+// Perlin3Fixed returns 3D Perlin noise at (x, y, z).
+func (n *Noise) Perlin3Fixed(x, y, z int32) int32 {
+	ix, iy, iz := int(x>>fixedShift), int(y>>fixedShift), int(z>>fixedShift)
+	fx, fy, fz := int64(x&fixedMask), int64(y&fixedMask), int64(z&fixedMask)
+	u, v, w := fadeFixed(fx), fadeFixed(fy), fadeFixed(fz)
+
+	c := func(dx, dy, dz int) int64 {
+		return grad3Fixed(n.hash3(ix+dx, iy+dy, iz+dz), fx-int64(dx)*fixedOne, fy-int64(dy)*fixedOne, fz-int64(dz)*fixedOne)
+	}
+	a := lerpFixed(
+		lerpFixed(c(0, 0, 0), c(1, 0, 0), u),
+		lerpFixed(c(0, 1, 0), c(1, 1, 0), u),
+		v)
+	b := lerpFixed(
+		lerpFixed(c(0, 0, 1), c(1, 0, 1), u),
+		lerpFixed(c(0, 1, 1), c(1, 1, 1), u),
+		v)
+	return int32(lerpFixed(a, b, w))
+}
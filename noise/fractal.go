@@ -0,0 +1,86 @@
+package noise
+
+import "math"
+
+// Fractal sums octaves of noise.
+// Each octave has a higher frequency and lower amplitude than the previous.
+type Fractal struct {
+	Octaves int
+	// Lacunarity is the frequency multiplier between octaves, usually 2.
+	Lacunarity float64
+	// Gain is the amplitude multiplier between octaves, usually 0.5.
+	Gain float64
+}
+
+// DefaultFractal has 5 octaves, each doubling the frequency and halving the amplitude.
+var DefaultFractal = Fractal{Octaves: 5, Lacunarity: 2, Gain: 0.5}
+
+// FBM2 returns fractal Brownian motion of fn at (x, y).
+// The result is normalized to -1 -> 1.
+func (f Fractal) FBM2(fn Func2, x, y float64) float64 {
+	var sum, total float64
+	amp := 1.0
+	for i := 0; i < f.Octaves; i++ {
+		sum += fn(x, y) * amp
+		total += amp
+		x *= f.Lacunarity
+		y *= f.Lacunarity
+		amp *= f.Gain
+	}
+	return sum / total
+}
+
+// FBM3 returns fractal Brownian motion of fn at (x, y, z).
+// The result is normalized to -1 -> 1.
+func (f Fractal) FBM3(fn Func3, x, y, z float64) float64 {
+	var sum, total float64
+	amp := 1.0
+	for i := 0; i < f.Octaves; i++ {
+		sum += fn(x, y, z) * amp
+		total += amp
+		x *= f.Lacunarity
+		y *= f.Lacunarity
+		z *= f.Lacunarity
+		amp *= f.Gain
+	}
+	return sum / total
+}
+
+// ridge turns noise into sharp ridges where it crosses 0.
+func ridge(v float64) float64 {
+	v = 1 - math.Abs(v)
+	return v * v
+}
+
+// Ridged2 returns ridged noise of fn at (x, y), for mountains and lightning.
+// The result is normalized to -1 -> 1.
+func (f Fractal) Ridged2(fn Func2, x, y float64) float64 {
+	return f.FBM2(func(x, y float64) float64 { return ridge(fn(x, y))*2 - 1 }, x, y)
+}
+
+// Ridged3 returns ridged noise of fn at (x, y, z).
+// The result is normalized to -1 -> 1.
+func (f Fractal) Ridged3(fn Func3, x, y, z float64) float64 {
+	return f.FBM3(func(x, y, z float64) float64 { return ridge(fn(x, y, z))*2 - 1 }, x, y, z)
+}
+
+// FBM2Tile returns fBm of Perlin noise that repeats every px, py units.
+// Lacunarity is rounded to a whole number, so all octaves repeat.
+func (n *Noise) FBM2Tile(x, y float64, px, py int, f Fractal) float64 {
+	lac := int(f.Lacunarity + 0.5)
+	if lac < 1 {
+		lac = 1
+	}
+	var sum, total float64
+	amp := 1.0
+	for i := 0; i < f.Octaves; i++ {
+		sum += n.Perlin2Tile(x, y, px, py) * amp
+		total += amp
+		x *= float64(lac)
+		y *= float64(lac)
+		px *= lac
+		py *= lac
+		amp *= f.Gain
+	}
+	return sum / total
+}
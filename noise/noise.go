@@ -0,0 +1,78 @@
+// Package noise generates coherent noise.
+//
+// Perlin, simplex, value and cellular (Worley) noise are available in 2, 3
+// and some in 4 dimensions. Perlin, simplex and value noise return values
+// in about the range -1 -> 1, cellular noise returns distances.
+//
+// Fractal combines octaves of any of the noise functions into fBm
+// or ridged noise and the tileable variants produce textures that
+// repeat seamlessly, for the tunnel and rotozoomer.
+//
+// Functions ending with Fixed take and return 16.16 fixed point values.
+package noise
+
+import "github.com/klauspost/gad/rng"
+
+// Noise generates noise from a seed.
+// The same seed always returns the same noise.
+type Noise struct {
+	// Permutation of 0 -> 255, repeated so indexes up to 511 are valid.
+	perm [512]uint8
+}
+
+// New returns noise with the given seed.
+func New(seed int64) *Noise {
+	var n Noise
+	for i, v := range rng.New(seed).Perm(256) {
+		n.perm[i] = uint8(v)
+		n.perm[i+256] = uint8(v)
+	}
+	return &n
+}
+
+// Func2 is a 2D noise function, for example Noise.Perlin2.
+type Func2 func(x, y float64) float64
+
+// Func3 is a 3D noise function, for example Noise.Simplex3.
+type Func3 func(x, y, z float64) float64
+
+// hash2 returns the hash of a lattice point.
+func (n *Noise) hash2(x, y int) int {
+	return int(n.perm[int(n.perm[x&255])+y&255])
+}
+
+func (n *Noise) hash3(x, y, z int) int {
+	return int(n.perm[int(n.perm[int(n.perm[x&255])+y&255])+z&255])
+}
+
+func (n *Noise) hash4(x, y, z, w int) int {
+	return int(n.perm[int(n.perm[int(n.perm[int(n.perm[x&255])+y&255])+z&255])+w&255])
+}
+
+// fade is the quintic ease curve 6t^5 - 15t^4 + 10t^3.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// floor returns the integer below v.
+// It is faster than math.Floor and is exact within the range of int.
+func floor(v float64) int {
+	i := int(v)
+	if v < float64(i) {
+		return i - 1
+	}
+	return i
+}
+
+// mod returns x modulo m in the range 0 -> m-1.
+func mod(x, m int) int {
+	x %= m
+	if x < 0 {
+		x += m
+	}
+	return x
+}
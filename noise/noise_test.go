@@ -0,0 +1,183 @@
+package noise
+
+import (
+	"math"
+	"testing"
+)
+
+// sample calls fn on a pseudo random spread of points in -100 -> 100.
+func sample(n int, fn func(x, y, z, w float64)) {
+	for i := 0; i < n; i++ {
+		f := float64(i)
+		fn(math.Mod(f*0.7548776662466927, 1)*200-100,
+			math.Mod(f*0.5698402909980532, 1)*200-100,
+			math.Mod(f*0.3141592653589793, 1)*200-100,
+			math.Mod(f*0.1234567890123456, 1)*200-100)
+	}
+}
+
+func TestRange(t *testing.T) {
+	n := New(1)
+	funcs := map[string]func(x, y, z, w float64) float64{
+		"Perlin2":  func(x, y, _, _ float64) float64 { return n.Perlin2(x, y) },
+		"Perlin3":  func(x, y, z, _ float64) float64 { return n.Perlin3(x, y, z) },
+		"Perlin4":  func(x, y, z, w float64) float64 { return n.Perlin4(x, y, z, w) },
+		"Simplex2": func(x, y, _, _ float64) float64 { return n.Simplex2(x, y) },
+		"Simplex3": func(x, y, z, _ float64) float64 { return n.Simplex3(x, y, z) },
+		"Simplex4": func(x, y, z, w float64) float64 { return n.Simplex4(x, y, z, w) },
+		"Value2":   func(x, y, _, _ float64) float64 { return n.Value2(x, y) },
+		"Value3":   func(x, y, z, _ float64) float64 { return n.Value3(x, y, z) },
+		"FBM2": func(x, y, _, _ float64) float64 {
+			return DefaultFractal.FBM2(n.Perlin2, x, y)
+		},
+		"Ridged3": func(x, y, z, _ float64) float64 {
+			return DefaultFractal.Ridged3(n.Simplex3, x, y, z)
+		},
+		"Perlin2Fixed": func(x, y, _, _ float64) float64 {
+			return float64(n.Perlin2Fixed(int32(x*fixedOne), int32(y*fixedOne))) / fixedOne
+		},
+		"Perlin3Fixed": func(x, y, z, _ float64) float64 {
+			return float64(n.Perlin3Fixed(int32(x*fixedOne), int32(y*fixedOne), int32(z*fixedOne))) / fixedOne
+		},
+	}
+	for name, fn := range funcs {
+		min, max := math.Inf(1), math.Inf(-1)
+		sample(20000, func(x, y, z, w float64) {
+			v := fn(x, y, z, w)
+			min, max = math.Min(min, v), math.Max(max, v)
+		})
+		if min < -1.05 || max > 1.05 {
+			t.Errorf("%s: range %.3f -> %.3f", name, min, max)
+		}
+		// The range must also be used.
+		if min > -0.3 || max < 0.3 {
+			t.Errorf("%s: range only %.3f -> %.3f", name, min, max)
+		}
+	}
+}
+
+func TestFixedMatchesFloat(t *testing.T) {
+	n := New(2)
+	sample(5000, func(x, y, z, _ float64) {
+		fx, fy, fz := int32(x*fixedOne), int32(y*fixedOne), int32(z*fixedOne)
+		x, y, z = float64(fx)/fixedOne, float64(fy)/fixedOne, float64(fz)/fixedOne
+		if d := math.Abs(float64(n.Perlin2Fixed(fx, fy))/fixedOne - n.Perlin2(x, y)); d > 0.001 {
+			t.Fatalf("Perlin2Fixed(%v, %v): off by %g", x, y, d)
+		}
+		if d := math.Abs(float64(n.Perlin3Fixed(fx, fy, fz))/fixedOne - n.Perlin3(x, y, z)); d > 0.001 {
+			t.Fatalf("Perlin3Fixed(%v, %v, %v): off by %g", x, y, z, d)
+		}
+	})
+}
+
+func TestCellular(t *testing.T) {
+	n := New(3)
+	sample(5000, func(x, y, z, _ float64) {
+		f1, f2 := n.Cellular2(x, y)
+		if f1 < 0 || f2 < f1 || f1 > math.Sqrt2 {
+			t.Fatalf("Cellular2(%v, %v): got %v, %v", x, y, f1, f2)
+		}
+		f1, f2 = n.Cellular3(x, y, z)
+		if f1 < 0 || f2 < f1 {
+			t.Fatalf("Cellular3(%v, %v, %v): got %v, %v", x, y, z, f1, f2)
+		}
+	})
+}
+
+func TestSeed(t *testing.T) {
+	a, b, c := New(4), New(4), New(5)
+	same, differ := true, false
+	sample(100, func(x, y, _, _ float64) {
+		same = same && a.Perlin2(x, y) == b.Perlin2(x, y)
+		differ = differ || a.Perlin2(x, y) != c.Perlin2(x, y)
+	})
+	if !same {
+		t.Error("same seed gives different noise")
+	}
+	if !differ {
+		t.Error("different seeds give the same noise")
+	}
+}
+
+func TestTile(t *testing.T) {
+	const eps = 1e-9
+	n := New(6)
+	tiles := map[string]func(x, y, z float64, px, py, pz int) float64{
+		"Perlin2Tile": func(x, y, _ float64, px, py, _ int) float64 { return n.Perlin2Tile(x, y, px, py) },
+		"Perlin3Tile": func(x, y, z float64, px, py, pz int) float64 { return n.Perlin3Tile(x, y, z, px, py, pz) },
+		"Cellular2Tile": func(x, y, _ float64, px, py, _ int) float64 {
+			f1, f2 := n.Cellular2Tile(x, y, px, py)
+			return f2 - f1
+		},
+		"FBM2Tile": func(x, y, _ float64, px, py, _ int) float64 {
+			return n.FBM2Tile(x, y, px, py, DefaultFractal)
+		},
+	}
+	for name, fn := range tiles {
+		for _, p := range [][3]int{{1, 1, 1}, {3, 5, 2}, {8, 8, 8}} {
+			px, py, pz := p[0], p[1], p[2]
+			sample(500, func(x, y, z, _ float64) {
+				// Keep the points inside the first period.
+				x = math.Mod(x+100, float64(px))
+				y = math.Mod(y+100, float64(py))
+				z = math.Mod(z+100, float64(pz))
+				v := fn(x, y, z, px, py, pz)
+				// Moving whole periods, 2D functions ignore z.
+				for _, d := range [][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {-2, 3, -1}} {
+					w := fn(x+d[0]*float64(px), y+d[1]*float64(py), z+d[2]*float64(pz), px, py, pz)
+					if math.Abs(v-w) > eps {
+						t.Fatalf("%s period %v at (%v, %v, %v) moved %v: %v != %v", name, p, x, y, z, d, v, w)
+					}
+				}
+			})
+		}
+	}
+}
+
+// wrapError returns the largest difference between neighbours across
+// the edges of a w * h image and inside it.
+func wrapError(pix []uint8, w, h int) (edge, inside int) {
+	diff := func(x0, y0, x1, y1 int) int {
+		d := int(pix[y0*w+x0]) - int(pix[(y1%h)*w+x1%w])
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for _, d := range [][2]int{{1, 0}, {0, 1}} {
+				v := diff(x, y, x+d[0], y+d[1])
+				if (d[0] == 1 && x == w-1) || (d[1] == 1 && y == h-1) {
+					if v > edge {
+						edge = v
+					}
+				} else if v > inside {
+					inside = v
+				}
+			}
+		}
+	}
+	return edge, inside
+}
+
+func TestTextureWrap(t *testing.T) {
+	n := New(7)
+	perlin, err := n.PerlinTexture(64, 32, 4, DefaultFractal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells, err := n.CellularTexture(64, 32, 4, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, img := range map[string][]uint8{"perlin": perlin.Pix, "cellular": cells.Pix} {
+		edge, inside := wrapError(img, 64, 32)
+		if edge > inside {
+			t.Errorf("%s: step of %d at the wrap edge, at most %d inside", name, edge, inside)
+		}
+	}
+	if _, err := Texture(60, 32, nil); err == nil {
+		t.Error("size not a power of two should fail")
+	}
+}
@@ -0,0 +1,162 @@
+package noise
+
+// Gradients are picked from the hash of each lattice point.
+
+// grad2 returns the dot product of one of 8 gradients and (x, y).
+func grad2(h int, x, y float64) float64 {
+	switch h & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return x - y
+	case 2:
+		return -x + y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+// grad3 returns the dot product of one of 12 gradients and (x, y, z).
+// The gradients point to the edges of a cube, 4 are repeated to get 16.
+func grad3(h int, x, y, z float64) float64 {
+	switch h & 15 {
+	case 0, 12:
+		return x + y
+	case 1, 13:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x + z
+	case 5:
+		return -x + z
+	case 6:
+		return x - z
+	case 7:
+		return -x - z
+	case 8:
+		return y + z
+	case 9, 14:
+		return -y + z
+	case 10:
+		return y - z
+	default:
+		return -y - z
+	}
+}
+
+// grad4 returns the dot product of one of 32 gradients and (x, y, z, w).
+// The gradients point to the edges of a hypercube.
+func grad4(h int, x, y, z, w float64) float64 {
+	h &= 31
+	// Pick 3 of the 4 axes and a sign for each.
+	var a, b, c float64
+	switch h >> 3 {
+	case 0:
+		a, b, c = y, z, w
+	case 1:
+		a, b, c = x, z, w
+	case 2:
+		a, b, c = x, y, w
+	default:
+		a, b, c = x, y, z
+	}
+	if h&4 != 0 {
+		a = -a
+	}
+	if h&2 != 0 {
+		b = -b
+	}
+	if h&1 != 0 {
+		c = -c
+	}
+	return a + b + c
+}
+
+// Perlin2 returns 2D Perlin noise at (x, y).
+func (n *Noise) Perlin2(x, y float64) float64 {
+	return n.Perlin2Tile(x, y, 256, 256)
+}
+
+// Perlin2Tile returns 2D Perlin noise that repeats every px, py units.
+// Periods must be at least 1.
+func (n *Noise) Perlin2Tile(x, y float64, px, py int) float64 {
+	ix, iy := floor(x), floor(y)
+	x -= float64(ix)
+	y -= float64(iy)
+	x0, y0 := mod(ix, px), mod(iy, py)
+	x1, y1 := mod(x0+1, px), mod(y0+1, py)
+	u, v := fade(x), fade(y)
+
+	a := lerp(grad2(n.hash2(x0, y0), x, y), grad2(n.hash2(x1, y0), x-1, y), u)
+	b := lerp(grad2(n.hash2(x0, y1), x, y-1), grad2(n.hash2(x1, y1), x-1, y-1), u)
+	return lerp(a, b, v)
+}
+
+// Perlin3 returns 3D Perlin noise at (x, y, z).
+func (n *Noise) Perlin3(x, y, z float64) float64 {
+	return n.Perlin3Tile(x, y, z, 256, 256, 256)
+}
+
+// Perlin3Tile returns 3D Perlin noise that repeats every px, py, pz units.
+// Periods must be at least 1.
+// Use it with z as time to animate a tileable texture.
+func (n *Noise) Perlin3Tile(x, y, z float64, px, py, pz int) float64 {
+	ix, iy, iz := floor(x), floor(y), floor(z)
+	x -= float64(ix)
+	y -= float64(iy)
+	z -= float64(iz)
+	x0, y0, z0 := mod(ix, px), mod(iy, py), mod(iz, pz)
+	x1, y1, z1 := mod(x0+1, px), mod(y0+1, py), mod(z0+1, pz)
+	u, v, w := fade(x), fade(y), fade(z)
+
+	c := func(xi, yi, zi int, dx, dy, dz float64) float64 {
+		return grad3(n.hash3(xi, yi, zi), x-dx, y-dy, z-dz)
+	}
+	a := lerp(
+		lerp(c(x0, y0, z0, 0, 0, 0), c(x1, y0, z0, 1, 0, 0), u),
+		lerp(c(x0, y1, z0, 0, 1, 0), c(x1, y1, z0, 1, 1, 0), u),
+		v)
+	b := lerp(
+		lerp(c(x0, y0, z1, 0, 0, 1), c(x1, y0, z1, 1, 0, 1), u),
+		lerp(c(x0, y1, z1, 0, 1, 1), c(x1, y1, z1, 1, 1, 1), u),
+		v)
+	return lerp(a, b, w)
+}
+
+// Perlin4 returns 4D Perlin noise at (x, y, z, w).
+func (n *Noise) Perlin4(x, y, z, w float64) float64 {
+	ix, iy, iz, iw := floor(x), floor(y), floor(z), floor(w)
+	x -= float64(ix)
+	y -= float64(iy)
+	z -= float64(iz)
+	w -= float64(iw)
+	fx, fy, fz, fw := fade(x), fade(y), fade(z), fade(w)
+
+	c := func(dx, dy, dz, dw int) float64 {
+		h := n.hash4(ix+dx, iy+dy, iz+dz, iw+dw)
+		return grad4(h, x-float64(dx), y-float64(dy), z-float64(dz), w-float64(dw))
+	}
+	cube := func(dw int) float64 {
+		a := lerp(
+			lerp(c(0, 0, 0, dw), c(1, 0, 0, dw), fx),
+			lerp(c(0, 1, 0, dw), c(1, 1, 0, dw), fx),
+			fy)
+		b := lerp(
+			lerp(c(0, 0, 1, dw), c(1, 0, 1, dw), fx),
+			lerp(c(0, 1, 1, dw), c(1, 1, 1, dw), fx),
+			fy)
+		return lerp(a, b, fz)
+	}
+	return lerp(cube(0), cube(1), fw)
+}
@@ -0,0 +1,127 @@
+package noise
+
+// Simplex noise by Ken Perlin, following the description by Stefan Gustavson.
+// It has fewer directional artifacts than Perlin noise and is faster
+// in higher dimensions.
+
+const (
+	// Skew and unskew factors: (sqrt(n+1)-1)/n and (n+1-sqrt(n+1))/(n*(n+1)).
+	skew2   = 0.36602540378443864676
+	unskew2 = 0.21132486540518711775
+	skew3   = 1.0 / 3
+	unskew3 = 1.0 / 6
+	skew4   = 0.30901699437494742410
+	unskew4 = 0.13819660112501051518
+)
+
+// Simplex2 returns 2D simplex noise at (x, y).
+func (n *Noise) Simplex2(x, y float64) float64 {
+	s := (x + y) * skew2
+	i, j := floor(x+s), floor(y+s)
+	t := float64(i+j) * unskew2
+	// Distances from the first corner.
+	x0, y0 := x-(float64(i)-t), y-(float64(j)-t)
+
+	// Pick the triangle we are in.
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+	x1, y1 := x0-float64(i1)+unskew2, y0-float64(j1)+unskew2
+	x2, y2 := x0-1+2*unskew2, y0-1+2*unskew2
+
+	corner := func(h int, x, y float64) float64 {
+		t := 0.5 - x*x - y*y
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		return t * t * grad2(h, x, y)
+	}
+	return 70 * (corner(n.hash2(i, j), x0, y0) +
+		corner(n.hash2(i+i1, j+j1), x1, y1) +
+		corner(n.hash2(i+1, j+1), x2, y2))
+}
+
+// Simplex3 returns 3D simplex noise at (x, y, z).
+func (n *Noise) Simplex3(x, y, z float64) float64 {
+	s := (x + y + z) * skew3
+	i, j, k := floor(x+s), floor(y+s), floor(z+s)
+	t := float64(i+j+k) * unskew3
+	x0, y0, z0 := x-(float64(i)-t), y-(float64(j)-t), z-(float64(k)-t)
+
+	// Pick the tetrahedron we are in from the order of the coordinates.
+	var i1, j1, k1, i2, j2, k2 int
+	switch {
+	case x0 >= y0 && y0 >= z0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+	case x0 >= z0 && z0 >= y0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+	case z0 >= x0 && x0 >= y0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+	case z0 >= y0 && y0 >= x0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+	case y0 >= z0 && z0 >= x0:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+	default:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+	}
+
+	corner := func(h int, x, y, z float64) float64 {
+		t := 0.6 - x*x - y*y - z*z
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		return t * t * grad3(h, x, y, z)
+	}
+	return 32 * (corner(n.hash3(i, j, k), x0, y0, z0) +
+		corner(n.hash3(i+i1, j+j1, k+k1), x0-float64(i1)+unskew3, y0-float64(j1)+unskew3, z0-float64(k1)+unskew3) +
+		corner(n.hash3(i+i2, j+j2, k+k2), x0-float64(i2)+2*unskew3, y0-float64(j2)+2*unskew3, z0-float64(k2)+2*unskew3) +
+		corner(n.hash3(i+1, j+1, k+1), x0-1+3*unskew3, y0-1+3*unskew3, z0-1+3*unskew3))
+}
+
+// Simplex4 returns 4D simplex noise at (x, y, z, w).
+// Use it with z, w on a circle to animate 3D noise in a loop.
+func (n *Noise) Simplex4(x, y, z, w float64) float64 {
+	s := (x + y + z + w) * skew4
+	i, j, k, l := floor(x+s), floor(y+s), floor(z+s), floor(w+s)
+	t := float64(i+j+k+l) * unskew4
+	d := [4]float64{x - (float64(i) - t), y - (float64(j) - t), z - (float64(k) - t), w - (float64(l) - t)}
+
+	// Rank the coordinates. The simplex is walked from the
+	// largest coordinate to the smallest.
+	var rank [4]int
+	for a := 0; a < 4; a++ {
+		for b := a + 1; b < 4; b++ {
+			if d[a] > d[b] {
+				rank[a]++
+			} else {
+				rank[b]++
+			}
+		}
+	}
+
+	corner := func(h int, x, y, z, w float64) float64 {
+		t := 0.6 - x*x - y*y - z*z - w*w
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		return t * t * grad4(h, x, y, z, w)
+	}
+	var sum float64
+	for c := 0; c <= 4; c++ {
+		// Corner c has offset 1 on the c axes with the highest rank.
+		var o [4]int
+		for a := range o {
+			if rank[a] >= 4-c {
+				o[a] = 1
+			}
+		}
+		u := float64(c) * unskew4
+		sum += corner(n.hash4(i+o[0], j+o[1], k+o[2], l+o[3]),
+			d[0]-float64(o[0])+u, d[1]-float64(o[1])+u, d[2]-float64(o[2])+u, d[3]-float64(o[3])+u)
+	}
+	return 27 * sum
+}
@@ -0,0 +1,60 @@
+package noise
+
+import (
+	"errors"
+	"image"
+)
+
+// Texture returns a w * h texture from fn.
+// fn is called with u, v in the range 0 -> 1 for each pixel
+// and values -1 -> 1 are mapped to 0 -> 255.
+// Width and height must be powers of two, so the texture can be
+// wrapped with a mask like the tunnel and rotozoomer do.
+func Texture(w, h int, fn func(u, v float64) float64) (*image.Gray, error) {
+	if w <= 0 || h <= 0 || w&(w-1) != 0 || h&(h-1) != 0 {
+		return nil, errors.New("noise: texture size must be a power of two")
+	}
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		line := img.Pix[y*img.Stride : y*img.Stride+w]
+		v := float64(y) / float64(h)
+		for x := range line {
+			f := fn(float64(x)/float64(w), v)
+			line[x] = toByte(f)
+		}
+	}
+	return img, nil
+}
+
+// toByte maps -1 -> 1 to 0 -> 255.
+func toByte(f float64) uint8 {
+	f = (f + 1) * 127.5
+	if f <= 0 {
+		return 0
+	}
+	if f >= 255 {
+		return 255
+	}
+	return uint8(f + 0.5)
+}
+
+// PerlinTexture returns a tileable w * h texture of fBm Perlin noise.
+// The first octave has cells * cells noise cells across the texture.
+func (n *Noise) PerlinTexture(w, h, cells int, f Fractal) (*image.Gray, error) {
+	return Texture(w, h, func(u, v float64) float64 {
+		return n.FBM2Tile(u*float64(cells), v*float64(cells), cells, cells, f)
+	})
+}
+
+// CellularTexture returns a tileable w * h texture of cellular noise
+// with cells * cells cells. Cell centers are dark.
+// If cracks is set the cell edges are dark instead.
+func (n *Noise) CellularTexture(w, h, cells int, cracks bool) (*image.Gray, error) {
+	return Texture(w, h, func(u, v float64) float64 {
+		f1, f2 := n.Cellular2Tile(u*float64(cells), v*float64(cells), cells, cells)
+		if cracks {
+			return (f2-f1)*4 - 1
+		}
+		return f1*2 - 1
+	})
+}
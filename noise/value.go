@@ -0,0 +1,30 @@
+package noise
+
+// Value noise interpolates random values at lattice points.
+// It is cheaper than Perlin noise, but blockier.
+
+// value returns the random value -1 -> 1 of a hash.
+func value(h int) float64 {
+	return float64(h)*(2.0/255) - 1
+}
+
+// Value2 returns 2D value noise at (x, y).
+func (n *Noise) Value2(x, y float64) float64 {
+	ix, iy := floor(x), floor(y)
+	u, v := fade(x-float64(ix)), fade(y-float64(iy))
+	a := lerp(value(n.hash2(ix, iy)), value(n.hash2(ix+1, iy)), u)
+	b := lerp(value(n.hash2(ix, iy+1)), value(n.hash2(ix+1, iy+1)), u)
+	return lerp(a, b, v)
+}
+
+// Value3 returns 3D value noise at (x, y, z).
+func (n *Noise) Value3(x, y, z float64) float64 {
+	ix, iy, iz := floor(x), floor(y), floor(z)
+	u, v, w := fade(x-float64(ix)), fade(y-float64(iy)), fade(z-float64(iz))
+	c := func(dx, dy, dz int) float64 {
+		return value(n.hash3(ix+dx, iy+dy, iz+dz))
+	}
+	a := lerp(lerp(c(0, 0, 0), c(1, 0, 0), u), lerp(c(0, 1, 0), c(1, 1, 0), u), v)
+	b := lerp(lerp(c(0, 0, 1), c(1, 0, 1), u), lerp(c(0, 1, 1), c(1, 1, 1), u), v)
+	return lerp(a, b, w)
+}
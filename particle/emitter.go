@@ -0,0 +1,99 @@
+package particle
+
+import (
+	"math"
+	"sort"
+
+	"github.com/klauspost/gad/rng"
+)
+
+// Emitter returns start positions for new particles.
+type Emitter interface {
+	Position(r *rng.Rand) Vec3
+}
+
+// Point emits all particles from a single point.
+type Point struct{ Pos Vec3 }
+
+func (p Point) Position(*rng.Rand) Vec3 {
+	return p.Pos
+}
+
+// Box emits particles evenly inside a box.
+// It is also used for bounds of a particle system.
+type Box struct{ Min, Max Vec3 }
+
+func (b Box) Position(r *rng.Rand) Vec3 {
+	return Vec3{
+		X: b.Min.X + r.Float32()*(b.Max.X-b.Min.X),
+		Y: b.Min.Y + r.Float32()*(b.Max.Y-b.Min.Y),
+		Z: b.Min.Z + r.Float32()*(b.Max.Z-b.Min.Z),
+	}
+}
+
+// Contains returns whether v is inside the box.
+func (b Box) Contains(v Vec3) bool {
+	return v.X >= b.Min.X && v.X <= b.Max.X &&
+		v.Y >= b.Min.Y && v.Y <= b.Max.Y &&
+		v.Z >= b.Min.Z && v.Z <= b.Max.Z
+}
+
+// Wrap will move v inside the box, asteroid style.
+func (b Box) Wrap(v Vec3) Vec3 {
+	return Vec3{
+		X: wrap(v.X, b.Min.X, b.Max.X),
+		Y: wrap(v.Y, b.Min.Y, b.Max.Y),
+		Z: wrap(v.Z, b.Min.Z, b.Max.Z),
+	}
+}
+
+func wrap(n, min, max float32) float32 {
+	if n >= min && n <= max {
+		return n
+	}
+	n = float32(math.Mod(float64(n-min), float64(max-min)))
+	if n < 0 {
+		n += max - min
+	}
+	return min + n
+}
+
+// Mesh emits particles evenly on the surface of a triangle mesh.
+type Mesh struct {
+	Verts []Vec3
+	Tris  [][3]int
+
+	// Accumulated area of all triangles up to and including the index.
+	areas []float32
+}
+
+// NewMesh creates a mesh emitter from vertices and triangles.
+func NewMesh(verts []Vec3, tris [][3]int) *Mesh {
+	m := Mesh{Verts: verts, Tris: tris, areas: make([]float32, len(tris))}
+	var total float32
+	for i, t := range tris {
+		a, b, c := verts[t[0]], verts[t[1]], verts[t[2]]
+		total += b.Sub(a).Cross(c.Sub(a)).Len() * 0.5
+		m.areas[i] = total
+	}
+	return &m
+}
+
+func (m *Mesh) Position(r *rng.Rand) Vec3 {
+	if len(m.areas) == 0 {
+		return Vec3{}
+	}
+	// Pick a triangle weighted by area.
+	want := r.Float32() * m.areas[len(m.areas)-1]
+	i := sort.Search(len(m.areas), func(i int) bool { return m.areas[i] >= want })
+	if i >= len(m.Tris) {
+		i = len(m.Tris) - 1
+	}
+	t := m.Tris[i]
+	a, b, c := m.Verts[t[0]], m.Verts[t[1]], m.Verts[t[2]]
+
+	// Uniform point in triangle.
+	r1 := float32(math.Sqrt(float64(r.Float32())))
+	r2 := r.Float32()
+	return a.Scale(1 - r1).Add(b.Scale(r1 * (1 - r2))).Add(c.Scale(r1 * r2))
+}
@@ -0,0 +1,79 @@
+package particle
+
+// Force returns the acceleration of a particle at time t.
+type Force interface {
+	Accel(p *Particle, t float32) Vec3
+}
+
+// Gravity accelerates all particles equally.
+type Gravity struct{ G Vec3 }
+
+func (g Gravity) Accel(*Particle, float32) Vec3 {
+	return g.G
+}
+
+// Wind drags particles towards the wind velocity.
+// Heavy particles are affected less.
+type Wind struct {
+	Vel  Vec3
+	Drag float32
+}
+
+func (w Wind) Accel(p *Particle, _ float32) Vec3 {
+	return w.Vel.Sub(p.Vel).Scale(w.Drag / p.Mass)
+}
+
+// Vortex spins particles around an axis going through Center.
+// The strength falls off with the distance to the axis.
+// Radius is the distance where the strength is halved.
+type Vortex struct {
+	Center, Axis Vec3
+	Strength     float32
+	Radius       float32
+	// Pull will pull particles towards the axis.
+	Pull float32
+}
+
+func (v Vortex) Accel(p *Particle, _ float32) Vec3 {
+	d := p.Pos.Sub(v.Center)
+	// Remove the component along the axis.
+	axisLen2 := v.Axis.Dot(v.Axis)
+	if axisLen2 == 0 {
+		return Vec3{}
+	}
+	d = d.Sub(v.Axis.Scale(d.Dot(v.Axis) / axisLen2))
+	dist2 := d.Dot(d)
+	falloff := float32(1)
+	if v.Radius > 0 {
+		falloff = 1 / (1 + dist2/(v.Radius*v.Radius))
+	}
+	acc := v.Axis.Cross(d).Scale(v.Strength * falloff)
+	if v.Pull != 0 {
+		acc = acc.Sub(d.Scale(v.Pull * falloff))
+	}
+	return acc.Scale(1 / p.Mass)
+}
+
+// Turbulence adds smooth noise to the movement of particles.
+type Turbulence struct {
+	Strength float32
+	// Scale is the size of features in world space.
+	Scale float32
+	// Speed is how fast the noise changes over time.
+	Speed float32
+	Seed  uint32
+}
+
+func (n Turbulence) Accel(p *Particle, t float32) Vec3 {
+	inv := float32(1)
+	if n.Scale > 0 {
+		inv = 1 / n.Scale
+	}
+	x, y, z := p.Pos.X*inv, p.Pos.Y*inv, p.Pos.Z*inv+t*n.Speed
+	f := n.Strength / p.Mass
+	return Vec3{
+		X: f * valueNoise3(x, y, z, n.Seed),
+		Y: f * valueNoise3(x, y, z, n.Seed+1),
+		Z: f * valueNoise3(x, y, z, n.Seed+2),
+	}
+}
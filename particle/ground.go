@@ -0,0 +1,90 @@
+package particle
+
+// Ground is a surface particles can land on.
+// Since Y points down, a particle has landed when its Y is at or below the level.
+type Ground interface {
+	Level(x, z float32) float32
+}
+
+// Heightfield is a ground with a height at each point of a regular grid.
+// Outside the grid the ground is flat at Y.
+type Heightfield struct {
+	// Y is the level where the height is 0.
+	Y float32
+	// X and Z of the first grid point and the distance between points.
+	X, Z         float32
+	CellX, CellZ float32
+	// Number of grid points along X and Z.
+	W, D int
+	// Height above Y for each grid point, W points per row.
+	// Positive heights raise the ground.
+	Height []float32
+}
+
+// NewHeightfield returns a flat heightfield at level y covering the box
+// from (x0, z0) to (x1, z1) with w * d grid points.
+func NewHeightfield(y, x0, z0, x1, z1 float32, w, d int) *Heightfield {
+	return &Heightfield{
+		Y:      y,
+		X:      x0,
+		Z:      z0,
+		CellX:  (x1 - x0) / float32(w-1),
+		CellZ:  (z1 - z0) / float32(d-1),
+		W:      w,
+		D:      d,
+		Height: make([]float32, w*d),
+	}
+}
+
+// cell returns the grid cell and fractions for x, z.
+// ok is false if the position is outside the grid.
+func (h *Heightfield) cell(x, z float32) (ix, iz int, fx, fz float32, ok bool) {
+	gx := (x - h.X) / h.CellX
+	gz := (z - h.Z) / h.CellZ
+	if gx < 0 || gz < 0 {
+		return 0, 0, 0, 0, false
+	}
+	ix, iz = int(gx), int(gz)
+	if ix >= h.W-1 || iz >= h.D-1 {
+		return 0, 0, 0, 0, false
+	}
+	return ix, iz, gx - float32(ix), gz - float32(iz), true
+}
+
+// Level returns the ground level at x, z, interpolated between grid points.
+func (h *Heightfield) Level(x, z float32) float32 {
+	return h.Y - h.HeightAt(x, z)
+}
+
+// HeightAt returns the height above Y at x, z, interpolated between grid points.
+func (h *Heightfield) HeightAt(x, z float32) float32 {
+	ix, iz, fx, fz, ok := h.cell(x, z)
+	if !ok {
+		return 0
+	}
+	row0 := h.Height[iz*h.W+ix:]
+	row1 := h.Height[(iz+1)*h.W+ix:]
+	return lerp(lerp(row0[0], row0[1], fx), lerp(row1[0], row1[1], fx), fz)
+}
+
+// Add will raise the ground at x, z by amount.
+// The amount is spread over the nearest grid points.
+func (h *Heightfield) Add(x, z, amount float32) {
+	ix, iz, fx, fz, ok := h.cell(x, z)
+	if !ok {
+		return
+	}
+	row0 := h.Height[iz*h.W+ix:]
+	row1 := h.Height[(iz+1)*h.W+ix:]
+	row0[0] += amount * (1 - fx) * (1 - fz)
+	row0[1] += amount * fx * (1 - fz)
+	row1[0] += amount * (1 - fx) * fz
+	row1[1] += amount * fx * fz
+}
+
+// Clear will reset all heights to 0.
+func (h *Heightfield) Clear() {
+	for i := range h.Height {
+		h.Height[i] = 0
+	}
+}
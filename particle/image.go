@@ -0,0 +1,78 @@
+package particle
+
+import (
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/klauspost/gad/rng"
+)
+
+// Image emits particles on the pixels of an image, weighted by brightness.
+// Black pixels never emit particles.
+// Positions are in pixels with (0, 0) at the center of the image and Z = 0.
+type Image struct {
+	w, h int
+
+	// Accumulated brightness of all pixels up to and including the index.
+	weights []uint64
+}
+
+// NewImage creates an image emitter.
+// *image.Gray and *image.Paletted are read directly,
+// other images are converted to gray.
+func NewImage(img image.Image) *Image {
+	r := img.Bounds()
+	e := Image{w: r.Dx(), h: r.Dy(), weights: make([]uint64, r.Dx()*r.Dy())}
+	var total uint64
+	add := func(i int, v uint8) {
+		total += uint64(v)
+		e.weights[i] = total
+	}
+	switch img := img.(type) {
+	case *image.Gray:
+		for y := 0; y < e.h; y++ {
+			line := img.Pix[img.PixOffset(r.Min.X, r.Min.Y+y):][:e.w]
+			for x, v := range line {
+				add(y*e.w+x, v)
+			}
+		}
+	case *image.Paletted:
+		var lum [256]uint8
+		for i, c := range img.Palette {
+			lum[i] = color.GrayModel.Convert(c).(color.Gray).Y
+		}
+		for y := 0; y < e.h; y++ {
+			line := img.Pix[img.PixOffset(r.Min.X, r.Min.Y+y):][:e.w]
+			for x, idx := range line {
+				add(y*e.w+x, lum[idx])
+			}
+		}
+	default:
+		for y := 0; y < e.h; y++ {
+			for x := 0; x < e.w; x++ {
+				add(y*e.w+x, color.GrayModel.Convert(img.At(r.Min.X+x, r.Min.Y+y)).(color.Gray).Y)
+			}
+		}
+	}
+	return &e
+}
+
+func (e *Image) Position(r *rng.Rand) Vec3 {
+	if len(e.weights) == 0 || e.weights[len(e.weights)-1] == 0 {
+		return Vec3{}
+	}
+	// Pick a pixel weighted by brightness.
+	want := uint64(r.Int63n(int64(e.weights[len(e.weights)-1])))
+	i := sort.Search(len(e.weights), func(i int) bool { return e.weights[i] > want })
+	if i >= len(e.weights) {
+		i = len(e.weights) - 1
+	}
+
+	// Random position inside the pixel.
+	x, y := i%e.w, i/e.w
+	return Vec3{
+		X: float32(x) + r.Float32() - float32(e.w)*0.5,
+		Y: float32(y) + r.Float32() - float32(e.h)*0.5,
+	}
+}
@@ -0,0 +1,159 @@
+package particle
+
+import (
+	"sort"
+
+	"github.com/klauspost/gad/rng"
+)
+
+// Shape is a set of positions particles can form.
+type Shape []Vec3
+
+// Sample returns a shape of n positions from an emitter.
+func Sample(e Emitter, n int, seed int64) Shape {
+	r := rng.New(seed)
+	s := make(Shape, n)
+	for i := range s {
+		s[i] = e.Position(r)
+	}
+	return s
+}
+
+// Scale returns a copy of the shape scaled by f around (0, 0, 0).
+func (s Shape) Scale(f float32) Shape {
+	dst := make(Shape, len(s))
+	for i, v := range s {
+		dst[i] = v.Scale(f)
+	}
+	return dst
+}
+
+// assignAxis is the direction particles are sorted along when assigning.
+// It is tilted, so rows and columns of flat shapes do not give ties.
+var assignAxis = Vec3{X: 1, Y: 2.5, Z: 0.5}
+
+// Assign returns the positions of to, reordered so position i
+// is the target for particle i of from.
+// The returned shape has the same length as from;
+// if the shapes have different lengths positions of to are skipped or repeated.
+//
+// Particles are paired by their order along an axis, so particles keep
+// their relative position and don't cross the whole shape while moving.
+func Assign(from, to Shape) Shape {
+	dst := make(Shape, len(from))
+	if len(to) == 0 {
+		return dst
+	}
+	a, b := sortedAlong(from, assignAxis), sortedAlong(to, assignAxis)
+	for rank, i := range a {
+		dst[i] = to[b[rank*len(b)/len(a)]]
+	}
+	return dst
+}
+
+// sortedAlong returns the indexes of s sorted by position along axis.
+func sortedAlong(s Shape, axis Vec3) []int {
+	idx := make([]int, len(s))
+	key := make([]float32, len(s))
+	for i, v := range s {
+		idx[i] = i
+		key[i] = v.Dot(axis)
+	}
+	sort.Slice(idx, func(i, j int) bool { return key[idx[i]] < key[idx[j]] })
+	return idx
+}
+
+// Morph moves particles from one shape to the next, looping back to the first.
+// Times are in seconds, or any other unit as long as it is used for all of them.
+type Morph struct {
+	// Shapes all have the same number of positions.
+	// Position i of every shape belongs to particle i.
+	Shapes []Shape
+	// Hold is the time each shape is held and Move the time moving to the next.
+	Hold, Move float32
+	// Stagger delays the start of each particle by up to this time,
+	// so particles don't all leave at once. Must be less than Move.
+	Stagger float32
+
+	delay []float32
+}
+
+// NewMorph creates a morph between the shapes.
+// Each shape is assigned to the one before,
+// so all shapes get the number of positions of the first.
+func NewMorph(seed int64, hold, move, stagger float32, shapes ...Shape) *Morph {
+	m := Morph{Hold: hold, Move: move, Stagger: stagger}
+	if len(shapes) == 0 {
+		return &m
+	}
+	m.Shapes = make([]Shape, len(shapes))
+	m.Shapes[0] = shapes[0]
+	for i := 1; i < len(shapes); i++ {
+		m.Shapes[i] = Assign(m.Shapes[i-1], shapes[i])
+	}
+	r := rng.New(seed)
+	m.delay = make([]float32, len(shapes[0]))
+	for i := range m.delay {
+		m.delay[i] = r.Float32()
+	}
+	return &m
+}
+
+// Segment returns the shapes being moved between at time t
+// and how far the move is, 0 -> 1. While holding, f is 0.
+func (m *Morph) Segment(t float32) (from, to int, f float32) {
+	n := len(m.Shapes)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	seg := m.Hold + m.Move
+	loops := int(t / seg)
+	if t < 0 {
+		loops--
+	}
+	t -= float32(loops) * seg
+	from = loops % n
+	if from < 0 {
+		from += n
+	}
+	to = (from + 1) % n
+	if t <= m.Hold || m.Move <= 0 {
+		return from, to, 0
+	}
+	return from, to, (t - m.Hold) / m.Move
+}
+
+// Progress returns how far particle i has moved, 0 -> 1,
+// when the move is f done. Particles ease in and out.
+func (m *Morph) Progress(i int, f float32) float32 {
+	if f <= 0 {
+		return 0
+	}
+	if m.Move > 0 && m.Stagger > 0 {
+		s := m.Stagger / m.Move
+		f = (f - s*m.delay[i]) / (1 - s)
+	}
+	if f <= 0 {
+		return 0
+	}
+	if f >= 1 {
+		return 1
+	}
+	return smooth(f)
+}
+
+// At returns the positions of all particles at time t.
+// dst is used if it has enough capacity.
+func (m *Morph) At(t float32, dst []Vec3) []Vec3 {
+	from, to, f := m.Segment(t)
+	dst = dst[:0]
+	if len(m.Shapes) == 0 {
+		return dst
+	}
+	a, b := m.Shapes[from], m.Shapes[to]
+	for i := range a {
+		p := m.Progress(i, f)
+		dst = append(dst, a[i].Add(b[i].Sub(a[i]).Scale(p)))
+	}
+	return dst
+}
@@ -0,0 +1,36 @@
+package particle
+
+import (
+	"math"
+
+	"github.com/klauspost/gad/rng"
+)
+
+// valueNoise3 returns smooth noise in the range -1 -> 1.
+func valueNoise3(x, y, z float32, seed uint32) float32 {
+	fx, fy, fz := math.Floor(float64(x)), math.Floor(float64(y)), math.Floor(float64(z))
+	ix, iy, iz := int32(fx), int32(fy), int32(fz)
+	tx, ty, tz := smooth(x-float32(fx)), smooth(y-float32(fy)), smooth(z-float32(fz))
+
+	c := func(dx, dy, dz int32) float32 {
+		return hash3(ix+dx, iy+dy, iz+dz, seed)
+	}
+	x00 := lerp(c(0, 0, 0), c(1, 0, 0), tx)
+	x10 := lerp(c(0, 1, 0), c(1, 1, 0), tx)
+	x01 := lerp(c(0, 0, 1), c(1, 0, 1), tx)
+	x11 := lerp(c(0, 1, 1), c(1, 1, 1), tx)
+	return lerp(lerp(x00, x10, ty), lerp(x01, x11, ty), tz)
+}
+
+// hash3 returns a random value -1 -> 1 for a lattice point.
+func hash3(x, y, z int32, seed uint32) float32 {
+	return rng.HashFloat(seed, x, y, z)*2 - 1
+}
+
+func smooth(t float32) float32 {
+	return t * t * (3 - 2*t)
+}
+
+func lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,301 @@
+// Package particle contains a deterministic particle system.
+//
+// Particles are spawned by an Emitter and moved by a list of Forces.
+// The simulation runs with a fixed timestep, so a given seed will
+// always give the same result at a given time.
+package particle
+
+import (
+	"math"
+
+	"github.com/klauspost/gad/rng"
+)
+
+// Vec3 is a position or direction in world space.
+type Vec3 struct{ X, Y, Z float32 }
+
+func (v Vec3) Add(b Vec3) Vec3 {
+	return Vec3{X: v.X + b.X, Y: v.Y + b.Y, Z: v.Z + b.Z}
+}
+
+func (v Vec3) Sub(b Vec3) Vec3 {
+	return Vec3{X: v.X - b.X, Y: v.Y - b.Y, Z: v.Z - b.Z}
+}
+
+func (v Vec3) Scale(f float32) Vec3 {
+	return Vec3{X: v.X * f, Y: v.Y * f, Z: v.Z * f}
+}
+
+func (v Vec3) Dot(b Vec3) float32 {
+	return v.X*b.X + v.Y*b.Y + v.Z*b.Z
+}
+
+func (v Vec3) Cross(b Vec3) Vec3 {
+	return Vec3{
+		X: v.Y*b.Z - v.Z*b.Y,
+		Y: v.Z*b.X - v.X*b.Z,
+		Z: v.X*b.Y - v.Y*b.X,
+	}
+}
+
+func (v Vec3) Len() float32 {
+	return float32(math.Sqrt(float64(v.Dot(v))))
+}
+
+// Particle is a single live particle.
+type Particle struct {
+	Pos, Vel Vec3
+	// Age and lifetime in seconds.
+	// A Life of 0 means the particle lives forever.
+	Age, Life float32
+	// Size is the radius in world units.
+	Size float32
+	// Mass scales the effect of forces that are not gravity.
+	Mass float32
+	// Rand is a random value 0 -> 1 picked when the particle is born.
+	// It can be used to vary the look of each particle.
+	Rand float32
+}
+
+// Fade returns how much of the life of the particle is used, 0 -> 1.
+// Particles that live forever will always return 0.
+func (p *Particle) Fade() float32 {
+	if p.Life <= 0 {
+		return 0
+	}
+	return p.Age / p.Life
+}
+
+// Config describes how particles are emitted and simulated.
+type Config struct {
+	// Seed of the random number generator.
+	Seed int64
+
+	// Emitter provides start positions for new particles.
+	Emitter Emitter
+
+	// Burst is the number of particles emitted at time 0.
+	Burst int
+	// Rate is the number of particles emitted per second.
+	Rate float32
+	// Max is the maximum number of live particles.
+	// No particles are emitted while the maximum is reached.
+	Max int
+
+	// Min and max life in seconds. 0 means the particles live forever.
+	Life [2]float32
+	// Min and max size of new particles.
+	Size [2]float32
+	// Grow will scale the size over the lifetime.
+	// At the end of the life the size is Size * (1 + Grow).
+	Grow float32
+	// Min and max mass of new particles. If zero, mass is 1.
+	Mass [2]float32
+
+	// Velocity of new particles.
+	// A random value between -Spread and +Spread is added to each axis.
+	Velocity, Spread Vec3
+
+	// Forces applied to all particles on every step.
+	Forces []Force
+
+	// If Bounds is non-empty, particles outside are removed,
+	// or wrapped to the other side if Wrap is set.
+	Bounds Box
+	Wrap   bool
+
+	// Ground particles will land on, if not nil.
+	// Landed particles are removed, or moved to the top of
+	// the bounds if Wrap is set.
+	Ground Ground
+	// Land is called with every particle that lands on the ground.
+	// It may change the ground.
+	Land func(p *Particle)
+
+	// Step is the fixed timestep of the simulation.
+	// If 0, 1/60 second is used.
+	Step float32
+}
+
+// System is a particle system.
+type System struct {
+	Config
+	Particles []Particle
+
+	rnd *rng.Rand
+	// time is the simulated time and remain the time not simulated
+	// from the last call to At.
+	time, remain float32
+	// emitAcc contains fractional particles to emit.
+	emitAcc float32
+}
+
+// New creates a new particle system with the supplied config.
+func New(c Config) *System {
+	if c.Step <= 0 {
+		c.Step = 1.0 / 60
+	}
+	s := System{Config: c}
+	s.Reset()
+	return &s
+}
+
+// Reset will restart the simulation at time 0.
+func (s *System) Reset() {
+	s.rnd = rng.New(s.Seed)
+	s.Particles = s.Particles[:0]
+	s.time, s.remain, s.emitAcc = 0, 0, 0
+	for i := 0; i < s.Burst; i++ {
+		s.emit()
+	}
+}
+
+// At will advance the simulation to time t in seconds.
+// If t is before the current time, the simulation is restarted.
+// Changes made to the ground by Land are not reset.
+func (s *System) At(t float32) {
+	if t < s.time {
+		s.Reset()
+	}
+	for s.time+s.Step <= t {
+		s.step(s.Step)
+		s.time += s.Step
+	}
+	s.remain = t - s.time
+}
+
+func (s *System) step(dt float32) {
+	s.emitAcc += s.Rate * dt
+	for ; s.emitAcc >= 1; s.emitAcc-- {
+		s.emit()
+	}
+
+	bounded := s.Bounds.Min != s.Bounds.Max
+	parts := s.Particles
+	for i := 0; i < len(parts); i++ {
+		p := &parts[i]
+		p.Age += dt
+		if (p.Life > 0 && p.Age >= p.Life) || (bounded && !s.Wrap && !s.Bounds.Contains(p.Pos)) {
+			// Remove by replacing with the last.
+			parts[i] = parts[len(parts)-1]
+			parts = parts[:len(parts)-1]
+			i--
+			continue
+		}
+		var acc Vec3
+		for _, f := range s.Forces {
+			acc = acc.Add(f.Accel(p, s.time))
+		}
+		// Semi-implicit Euler.
+		p.Vel = p.Vel.Add(acc.Scale(dt))
+		p.Pos = p.Pos.Add(p.Vel.Scale(dt))
+		if s.Ground != nil && p.Pos.Y >= s.Ground.Level(p.Pos.X, p.Pos.Z) {
+			if s.Land != nil {
+				s.Land(p)
+			}
+			if !bounded || !s.Wrap {
+				parts[i] = parts[len(parts)-1]
+				parts = parts[:len(parts)-1]
+				i--
+				continue
+			}
+			p.Pos.Y = s.Bounds.Min.Y
+		}
+		if bounded && s.Wrap {
+			p.Pos = s.Bounds.Wrap(p.Pos)
+		}
+	}
+	s.Particles = parts
+}
+
+func (s *System) emit() {
+	if s.Max > 0 && len(s.Particles) >= s.Max {
+		return
+	}
+	r := s.rnd
+	var p Particle
+	if s.Emitter != nil {
+		p.Pos = s.Emitter.Position(r)
+	}
+	p.Vel = s.Velocity.Add(Vec3{
+		X: s.Spread.X * (r.Float32()*2 - 1),
+		Y: s.Spread.Y * (r.Float32()*2 - 1),
+		Z: s.Spread.Z * (r.Float32()*2 - 1),
+	})
+	p.Life = between(r, s.Life)
+	p.Size = between(r, s.Size)
+	p.Mass = between(r, s.Mass)
+	if p.Mass <= 0 {
+		p.Mass = 1
+	}
+	p.Rand = r.Float32()
+	s.Particles = append(s.Particles, p)
+}
+
+// Projection describes how particles are projected to the screen.
+type Projection struct {
+	// Screen space center.
+	CenterX, CenterY float32
+	// Scale of x and y, and of the radius.
+	Scale float32
+	// Particles closer than Near are not drawn.
+	Near float32
+	// Shrink is subtracted from the projected radius (in pixels).
+	Shrink float32
+
+	// Focus is the distance that is in focus.
+	Focus float32
+	// Aperture is the size of the lens.
+	// This is the blur radius in pixels of particles infinitely far away.
+	// If 0, everything is in focus.
+	Aperture float32
+}
+
+// Blur returns the radius in pixels that should be added to a particle at depth z
+// to simulate depth of field.
+func (proj Projection) Blur(z float32) float32 {
+	if proj.Aperture == 0 || z <= 0 {
+		return 0
+	}
+	d := z - proj.Focus
+	if d < 0 {
+		d = -d
+	}
+	return proj.Aperture * d / z
+}
+
+// Draw will project all particles and call fn for each particle in front of the camera.
+// Positions and radius is sent as 24.8 fixed point.
+func (s *System) Draw(proj Projection, fn func(x, y, r int32)) {
+	s.Project(proj, func(_ *Particle, x, y, r int32, _ float32) {
+		fn(x, y, r)
+	})
+}
+
+// Project will project all particles and call fn for each particle in front of the camera.
+// Positions and radius is sent as 24.8 fixed point and z is the depth of the particle.
+// This can be used to sort particles or vary their look.
+func (s *System) Project(proj Projection, fn func(p *Particle, x, y, r int32, z float32)) {
+	for i := range s.Particles {
+		p := &s.Particles[i]
+		// Extrapolate to the requested time.
+		pos := p.Pos.Add(p.Vel.Scale(s.remain))
+		if pos.Z < proj.Near || pos.Z <= 0 {
+			continue
+		}
+		invZ := proj.Scale / pos.Z
+		x := pos.X*invZ + proj.CenterX
+		y := pos.Y*invZ + proj.CenterY
+		r := s.size(p)*invZ - proj.Shrink
+		fn(p, int32(x*256), int32(y*256), int32(r*256), pos.Z)
+	}
+}
+
+// size returns the current size of p.
+func (s *System) size(p *Particle) float32 {
+	return p.Size * (1 + s.Grow*p.Fade())
+}
+
+func between(r *rng.Rand, v [2]float32) float32 {
+	return v[0] + r.Float32()*(v[1]-v[0])
+}
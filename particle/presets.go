@@ -0,0 +1,76 @@
+package particle
+
+// The presets below use the same axes as the screen,
+// so positive Y is down and positive Z is into the screen.
+
+// Fountain returns a config for a fountain spraying up from pos.
+func Fountain(pos Vec3) Config {
+	return Config{
+		Seed:     1,
+		Emitter:  Point{Pos: pos},
+		Rate:     400,
+		Max:      2000,
+		Life:     [2]float32{2, 3},
+		Size:     [2]float32{3, 6},
+		Grow:     -0.5,
+		Velocity: Vec3{Y: -300},
+		Spread:   Vec3{X: 40, Y: 40, Z: 40},
+		Forces:   []Force{Gravity{G: Vec3{Y: 250}}},
+	}
+}
+
+// Explosion returns a config for a single burst of particles from pos.
+func Explosion(pos Vec3) Config {
+	return Config{
+		Seed:    2,
+		Emitter: Point{Pos: pos},
+		Burst:   1500,
+		Life:    [2]float32{0.5, 2},
+		Size:    [2]float32{2, 8},
+		Grow:    -1,
+		Mass:    [2]float32{0.5, 2},
+		Spread:  Vec3{X: 400, Y: 400, Z: 400},
+		Forces: []Force{
+			Gravity{G: Vec3{Y: 100}},
+			Wind{Drag: 2},
+		},
+	}
+}
+
+// Smoke returns a config for smoke rising from a box around pos.
+func Smoke(pos Vec3) Config {
+	return Config{
+		Seed:     3,
+		Emitter:  Box{Min: pos.Sub(Vec3{X: 10, Z: 10}), Max: pos.Add(Vec3{X: 10, Z: 10})},
+		Rate:     60,
+		Max:      500,
+		Life:     [2]float32{3, 6},
+		Size:     [2]float32{10, 20},
+		Grow:     4,
+		Velocity: Vec3{Y: -40},
+		Spread:   Vec3{X: 5, Y: 10, Z: 5},
+		Forces: []Force{
+			Wind{Vel: Vec3{X: 20, Y: -40}, Drag: 0.5},
+			Turbulence{Strength: 30, Scale: 40, Speed: 0.5},
+		},
+	}
+}
+
+// Snow returns a config for snow falling inside bounds.
+// The box is filled at time 0 and flakes wrap around when leaving it.
+func Snow(bounds Box, flakes int) Config {
+	return Config{
+		Seed:    0xc0cac01a,
+		Emitter: bounds,
+		Burst:   flakes,
+		Size:    [2]float32{30, 30},
+		Mass:    [2]float32{0.25, 1.5},
+		Forces: []Force{
+			Gravity{G: Vec3{Y: 40}},
+			Wind{Vel: Vec3{X: 10}, Drag: 1},
+			Turbulence{Strength: 60, Scale: 200, Speed: 0.3},
+		},
+		Bounds: bounds,
+		Wrap:   true,
+	}
+}
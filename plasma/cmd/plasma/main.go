@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/klauspost/gad/plasma"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+func main() {
+	fx, err := plasma.New(renderWidth, renderHeight, plasma.Classic)
+	// Other presets:
+	//fx, err := plasma.New(renderWidth, renderHeight, plasma.Lava)
+	//fx, err := plasma.New(renderWidth, renderHeight, plasma.Rings)
+	//fx, err := plasma.New(renderWidth, renderHeight, plasma.Stripes)
+	if err != nil {
+		panic(err)
+	}
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
@@ -0,0 +1,214 @@
+// Package plasma renders the classic sine sum plasma.
+//
+// Each pixel is the sum of a number of sine waves looked up in a table,
+// used as an index into a palette.
+// The waves are animated by moving their offset into the table
+// and the palette is rotated on top of that.
+package plasma
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/klauspost/gad/fixed"
+)
+
+// Wave is a single sine wave of the plasma.
+type Wave struct {
+	// X and Y are the number of periods across the width and height of the screen.
+	// Setting both gives a diagonal wave.
+	X, Y float64
+
+	// Radial gives circular waves with this many periods from the center
+	// to the top of the screen. X and Y are ignored for radial waves.
+	Radial float64
+
+	// Orbit moves the center of radial waves in a circle around the middle of the screen.
+	// The radius is a fraction of the screen height, 0 -> 0.5.
+	// OrbitSpeed is the number of orbits per unit of time.
+	Orbit, OrbitSpeed float64
+
+	// Phase is the offset at t = 0 in periods.
+	Phase float64
+
+	// Speed is the number of periods the wave moves per unit of time.
+	// The plasma is seamless when t loops only if all speeds are whole.
+	Speed float64
+}
+
+// Config describes a plasma.
+type Config struct {
+	Waves []Wave
+
+	// Palette is stretched to 256 entries.
+	// Use a cycling palette, texture.NewPalette(true, ...), if it is rotated.
+	Palette color.Palette
+
+	// Cycle is the number of full palette rotations per unit of time.
+	Cycle float64
+}
+
+const (
+	sinBits = 10
+	// Bits of the angle below the table index.
+	sinShift = 16 - sinBits
+	// Distances are stored with this many fraction bits.
+	distBits = 4
+)
+
+type wave struct {
+	Wave
+	// Angle for each column and row of linear waves.
+	x, y []fixed.Angle
+	// Angle per distance unit of radial waves, as 24.8.
+	mul uint32
+}
+
+// Fx is a plasma effect.
+type Fx struct {
+	cfg   Config
+	waves []wave
+	// sin has the sine scaled, so the sum of all waves is within ±127 as 24.8.
+	sin [1 << sinBits]int32
+	// dist has the distance to the center of a 2w * 2h area.
+	// Radial waves use a w * h window of it.
+	dist    []uint16
+	pal     color.Palette
+	draw    *image.Paletted
+	lines   [][]byte
+	acc     []int32
+	w, h    int
+	hasDist bool
+}
+
+// New returns a plasma rendering w * h pixels.
+func New(w, h int, c Config) (*Fx, error) {
+	if len(c.Waves) == 0 {
+		return nil, errors.New("plasma: no waves")
+	}
+	if len(c.Palette) == 0 {
+		return nil, errors.New("plasma: empty palette")
+	}
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("plasma: invalid size")
+	}
+	fx := Fx{cfg: c, w: w, h: h}
+
+	amp := 127 * 256 / float64(len(c.Waves))
+	for i := range fx.sin {
+		fx.sin[i] = int32(math.Floor(math.Sin(float64(i)*2*math.Pi/float64(len(fx.sin)))*amp + 0.5))
+	}
+
+	for _, wv := range c.Waves {
+		v := wave{Wave: wv}
+		if wv.Radial != 0 {
+			// Angle per pixel, then per distance unit as 24.8.
+			perPixel := wv.Radial * 65536 / (float64(h) / 2)
+			v.mul = uint32(perPixel / (1 << distBits) * 256)
+			fx.hasDist = true
+		} else {
+			v.x = make([]fixed.Angle, w)
+			for x := range v.x {
+				v.x[x] = fixed.Radians(float64(x) * wv.X * 2 * math.Pi / float64(w))
+			}
+			v.y = make([]fixed.Angle, h)
+			for y := range v.y {
+				v.y[y] = fixed.Radians(float64(y) * wv.Y * 2 * math.Pi / float64(h))
+			}
+		}
+		fx.waves = append(fx.waves, v)
+	}
+
+	if fx.hasDist {
+		fx.dist = make([]uint16, 4*w*h)
+		for y := 0; y < 2*h; y++ {
+			dy := float64(y - h)
+			for x := 0; x < 2*w; x++ {
+				dx := float64(x - w)
+				fx.dist[y*2*w+x] = uint16(math.Sqrt(dx*dx+dy*dy) * (1 << distBits))
+			}
+		}
+	}
+
+	// Stretch the palette to 256 entries, so rotating is a simple mask.
+	fx.pal = make(color.Palette, 256)
+	for i := range fx.pal {
+		fx.pal[i] = c.Palette[i*len(c.Palette)/256]
+	}
+
+	// Create our draw buffer with its own palette, which is rotated.
+	fx.draw = image.NewPaletted(image.Rect(0, 0, w, h), append(color.Palette{}, fx.pal...))
+
+	// Store each line as a slice in a slice.
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+	fx.acc = make([]int32, w)
+	return &fx, nil
+}
+
+// Render the effect at time t.
+func (fx *Fx) Render(t float64) image.Image {
+	// Rotate palette.
+	rot := int(math.Floor(t*fx.cfg.Cycle*256)) & 255
+	for i := range fx.draw.Palette {
+		fx.draw.Palette[i] = fx.pal[(i+rot)&255]
+	}
+
+	// Offset into the sine table and window into the distance table for each wave.
+	phase := make([]fixed.Angle, len(fx.waves))
+	offset := make([]int, len(fx.waves))
+	for i, wv := range fx.waves {
+		phase[i] = fixed.Radians((wv.Phase + wv.Speed*t) * 2 * math.Pi)
+		if wv.Radial == 0 {
+			continue
+		}
+		a := wv.OrbitSpeed * t * 2 * math.Pi
+		r := wv.Orbit * float64(fx.h)
+		cx := clamp(fx.w/2+int(math.Cos(a)*r), 0, fx.w)
+		cy := clamp(fx.h/2+int(math.Sin(a)*r), 0, fx.h)
+		// Table center is at (w, h).
+		offset[i] = (fx.h-cy)*2*fx.w + fx.w - cx
+	}
+
+	sin := &fx.sin
+	acc := fx.acc
+	for y, line := range fx.lines {
+		for x := range acc {
+			acc[x] = 0
+		}
+		for i, wv := range fx.waves {
+			if wv.Radial != 0 {
+				off := offset[i] + y*2*fx.w
+				dist := fx.dist[off : off+len(acc)]
+				mul, ph := wv.mul, phase[i]
+				for x, d := range dist {
+					a := fixed.Angle((uint32(d)*mul)>>8) + ph
+					acc[x] += sin[a>>sinShift]
+				}
+				continue
+			}
+			base := wv.y[y] + phase[i]
+			for x, a := range wv.x {
+				acc[x] += sin[(a+base)>>sinShift]
+			}
+		}
+		for x, v := range acc {
+			line[x] = uint8(128 + v>>8)
+		}
+	}
+	return fx.draw
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
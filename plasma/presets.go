@@ -0,0 +1,78 @@
+package plasma
+
+import (
+	"image/color"
+
+	"github.com/klauspost/gad/texture"
+)
+
+// Palettes that cycle without a seam.
+var (
+	LavaPalette = texture.NewPalette(true,
+		color.RGBA{R: 20, A: 255},
+		color.RGBA{R: 200, G: 30, A: 255},
+		color.RGBA{R: 255, G: 200, B: 40, A: 255},
+		color.RGBA{R: 200, G: 30, A: 255},
+	)
+
+	OceanPalette = texture.NewPalette(true,
+		color.RGBA{B: 40, A: 255},
+		color.RGBA{G: 90, B: 160, A: 255},
+		color.RGBA{R: 120, G: 230, B: 255, A: 255},
+		color.RGBA{G: 60, B: 120, A: 255},
+	)
+
+	NeonPalette = texture.NewPalette(true,
+		color.RGBA{A: 255},
+		color.RGBA{R: 255, B: 200, A: 255},
+		color.RGBA{A: 255},
+		color.RGBA{G: 255, B: 220, A: 255},
+	)
+)
+
+// Presets.
+var (
+	// Classic is the sine sum plasma most demos had.
+	Classic = Config{
+		Waves: []Wave{
+			{X: 1.5, Speed: 1},
+			{Y: 1.25, Phase: 0.25, Speed: -2},
+			{X: 1, Y: 1, Speed: 1},
+			{Radial: 2, Orbit: 0.3, OrbitSpeed: 1, Speed: -3},
+		},
+		Palette: texture.RainbowPalette,
+		Cycle:   1,
+	}
+
+	// Lava has slow, large blobs.
+	Lava = Config{
+		Waves: []Wave{
+			{X: 0.75, Y: 0.25, Speed: 1},
+			{X: -0.25, Y: 0.75, Phase: 0.5, Speed: 1},
+			{Radial: 1, Orbit: 0.25, OrbitSpeed: 1, Speed: -1},
+		},
+		Palette: LavaPalette,
+		Cycle:   -1,
+	}
+
+	// Rings has interfering circles from two moving centers.
+	Rings = Config{
+		Waves: []Wave{
+			{Radial: 6, Orbit: 0.3, OrbitSpeed: 1, Speed: -4},
+			{Radial: 5, Orbit: 0.2, OrbitSpeed: -2, Phase: 0.5, Speed: -4},
+		},
+		Palette: OceanPalette,
+		Cycle:   2,
+	}
+
+	// Stripes only animates by rotating the palette.
+	Stripes = Config{
+		Waves: []Wave{
+			{X: 3},
+			{Y: 2},
+			{X: 2, Y: -1.5},
+		},
+		Palette: NeonPalette,
+		Cycle:   4,
+	}
+)
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/klauspost/gad/raycast"
+	"github.com/klauspost/gad/texture"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+var maze = raycast.ParseMap(
+	"################",
+	"#..............#",
+	"#.2222..3333...#",
+	"#.2..2..3..3...#",
+	"#.2..2.....3...#",
+	"#.2222..3333...#",
+	"#..............#",
+	"#...#....#.....#",
+	"#..............#",
+	"################",
+)
+
+// path goes around the inner walls, through the corridors.
+var path = [][2]float64{
+	{2, 1.5}, {7.5, 1.5}, {13, 1.5}, {13.5, 4}, {13, 6.5},
+	{11, 8.5}, {6.5, 8.5}, {2.5, 8.5}, {1.5, 6}, {1.5, 3.5},
+}
+
+func main() {
+	fx, err := raycast.New(renderWidth, renderHeight, raycast.Config{
+		Map:      maze,
+		Textures: textures(),
+		Floor:    3,
+		Ceiling:  4,
+		Camera:   raycast.Path(path...),
+		Dark:     12,
+	})
+	if err != nil {
+		panic(err)
+	}
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
+
+// Textures share a palette of 4 ramps with 64 colors each.
+var ramps = [][]color.Color{
+	{color.RGBA{R: 40, G: 20, B: 10, A: 255}, color.RGBA{R: 200, G: 90, B: 60, A: 255}},
+	{color.RGBA{R: 10, G: 30, B: 60, A: 255}, color.RGBA{R: 120, G: 180, B: 240, A: 255}},
+	{color.RGBA{R: 30, G: 30, B: 30, A: 255}, color.RGBA{R: 200, G: 200, B: 190, A: 255}},
+	{color.RGBA{R: 50, G: 30, B: 10, A: 255}, color.RGBA{R: 220, G: 170, B: 90, A: 255}},
+}
+
+// textures returns generated wall, floor and ceiling textures.
+func textures() []*image.Paletted {
+	var pal color.Palette
+	for _, r := range ramps {
+		full := texture.NewPalette(false, r...)
+		for i := 0; i < 256; i += 4 {
+			pal = append(pal, full[i])
+		}
+	}
+	patterns := []texture.Pattern{
+		ramp(0, texture.Invert(texture.Checker(4))),
+		ramp(1, texture.Truchet(1, 4)),
+		ramp(3, texture.Wood(1, 3)),
+		ramp(2, texture.Checker(2)),
+		ramp(2, texture.Clouds(2, 4)),
+	}
+	var imgs []*image.Paletted
+	for _, p := range patterns {
+		img, err := texture.Paletted(64, 64, p, pal)
+		if err != nil {
+			panic(err)
+		}
+		imgs = append(imgs, img)
+	}
+	return imgs
+}
+
+// ramp returns the pattern using the palette ramp n.
+func ramp(n int, p texture.Pattern) texture.Pattern {
+	return func(x, y, w, h int) uint8 {
+		return uint8(n*64) + p(x, y, w, h)>>2
+	}
+}
@@ -0,0 +1,59 @@
+package raycast
+
+import (
+	"math"
+)
+
+// ParseMap returns a map from rows of text.
+// Digits 1 -> 9 are walls with that texture, '#' is the same as '1' and
+// everything else is empty.
+func ParseMap(rows ...string) [][]uint8 {
+	m := make([][]uint8, len(rows))
+	for y, row := range rows {
+		m[y] = make([]uint8, len(row))
+		for x, c := range []byte(row) {
+			switch {
+			case c == '#':
+				m[y][x] = 1
+			case c >= '1' && c <= '9':
+				m[y][x] = c - '0'
+			}
+		}
+	}
+	return m
+}
+
+// Path returns a camera moving through the points at constant time per point,
+// looking in the direction it moves.
+// The path is a closed loop, smoothed with a Catmull-Rom spline,
+// so it passes through all points.
+// Use cell centers, like (1.5, 1.5), so the camera doesn't touch walls.
+func Path(points ...[2]float64) func(t float64) Camera {
+	n := len(points)
+	return func(t float64) Camera {
+		if n == 0 {
+			return Camera{}
+		}
+		f := t * float64(n)
+		i := int(math.Floor(f))
+		f -= float64(i)
+		at := func(j int) [2]float64 {
+			j %= n
+			if j < 0 {
+				j += n
+			}
+			return points[j]
+		}
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+		var pos, dir [2]float64
+		for k := range pos {
+			a := -p0[k] + 3*p1[k] - 3*p2[k] + p3[k]
+			b := 2*p0[k] - 5*p1[k] + 4*p2[k] - p3[k]
+			c := -p0[k] + p2[k]
+			d := 2 * p1[k]
+			pos[k] = 0.5 * (((a*f+b)*f+c)*f + d)
+			dir[k] = 0.5 * ((3*a*f+2*b)*f + c)
+		}
+		return Camera{X: pos[0], Y: pos[1], Heading: math.Atan2(dir[1], dir[0])}
+	}
+}
@@ -0,0 +1,327 @@
+// Package raycast renders a textured maze from a grid map,
+// like Wolfenstein 3D.
+//
+// For each screen column a ray is followed through the grid until it hits a wall,
+// which is drawn as a textured column scaled by the distance.
+// Floor and ceiling are drawn line by line, since every pixel of
+// a screen line has the same distance.
+//
+// Textures are sampled like the rotozoomer and tunnel,
+// so their sizes must be powers of two.
+// All textures must use the same palette, which is used for the output.
+// Everything is darkened with the distance using a shade table.
+package raycast
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Camera is the view of the maze.
+type Camera struct {
+	// Position on the map, in cells.
+	X, Y float64
+	// Heading in radians. 0 looks towards positive X and
+	// positive values turn towards positive Y.
+	Heading float64
+}
+
+// Config describes a maze.
+type Config struct {
+	// Map has a row of cells for each y.
+	// 0 is empty and other values are walls with texture value - 1.
+	// Everything outside the map is a wall with the first texture.
+	Map [][]uint8
+
+	// Textures used for walls, floor and ceiling.
+	Textures []*image.Paletted
+
+	// Floor and Ceiling are the texture indexes used for floor and ceiling.
+	Floor, Ceiling int
+
+	// Camera returns the camera at time t.
+	Camera func(t float64) Camera
+
+	// FOV is the horizontal field of view in radians. 0 gives 66 degrees.
+	FOV float64
+
+	// Dark is the distance in cells where everything is fully darkened.
+	// 0 disables distance shading.
+	Dark float64
+}
+
+// shadeLevels is the number of distance shades.
+const shadeLevels = 32
+
+type tex struct {
+	pix          []byte
+	maskX, maskY int
+	w, h         int
+	stride       int
+}
+
+// Fx is a raycasting effect.
+type Fx struct {
+	cfg   Config
+	tex   []tex
+	shade [shadeLevels][256]uint8
+	draw  *image.Paletted
+	lines [][]byte
+	w, h  int
+}
+
+// New returns a raycaster rendering w * h pixels.
+func New(w, h int, c Config) (*Fx, error) {
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("raycast: invalid size")
+	}
+	if len(c.Textures) == 0 {
+		return nil, errors.New("raycast: no textures")
+	}
+	if c.Floor < 0 || c.Floor >= len(c.Textures) || c.Ceiling < 0 || c.Ceiling >= len(c.Textures) {
+		return nil, errors.New("raycast: floor or ceiling texture out of range")
+	}
+	for _, row := range c.Map {
+		for _, v := range row {
+			if int(v) > len(c.Textures) {
+				return nil, errors.New("raycast: map uses missing texture")
+			}
+		}
+	}
+	if c.FOV == 0 {
+		c.FOV = 66 * math.Pi / 180
+	}
+	fx := Fx{cfg: c, w: w, h: h}
+
+	pal := c.Textures[0].Palette
+	for _, img := range c.Textures {
+		tw, th := img.Rect.Dx(), img.Rect.Dy()
+		if tw <= 0 || th <= 0 || tw&(tw-1) != 0 || th&(th-1) != 0 {
+			return nil, errors.New("raycast: texture size is not power of two")
+		}
+		if len(img.Palette) != len(pal) {
+			return nil, errors.New("raycast: textures must use the same palette")
+		}
+		fx.tex = append(fx.tex, tex{
+			pix:    img.Pix,
+			maskX:  tw - 1,
+			maskY:  th - 1,
+			w:      tw,
+			h:      th,
+			stride: img.Stride,
+		})
+	}
+	fx.initShade(pal)
+
+	// Create our draw buffer
+	fx.draw = image.NewPaletted(image.Rect(0, 0, w, h), pal)
+
+	// Store each line as a slice in a slice.
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+	return &fx, nil
+}
+
+// initShade calculates the closest palette entry for each color at each distance.
+func (fx *Fx) initShade(pal color.Palette) {
+	for l := range fx.shade {
+		// Brightness, 256 -> 0.
+		f := uint32(256 - l*256/(shadeLevels-1))
+		for i := range fx.shade[l] {
+			if i >= len(pal) {
+				break
+			}
+			r, g, b, a := pal[i].RGBA()
+			c := color.RGBA64{
+				R: uint16(r * f >> 8),
+				G: uint16(g * f >> 8),
+				B: uint16(b * f >> 8),
+				A: uint16(a),
+			}
+			fx.shade[l][i] = uint8(pal.Index(c))
+		}
+	}
+}
+
+// level returns the shade level at distance d.
+func (fx *Fx) level(d float64) int {
+	if fx.cfg.Dark <= 0 {
+		return 0
+	}
+	l := int(d * shadeLevels / fx.cfg.Dark)
+	if l >= shadeLevels {
+		return shadeLevels - 1
+	}
+	return l
+}
+
+// Render the effect at time t.
+func (fx *Fx) Render(t float64) image.Image {
+	return fx.Draw(fx.cfg.Camera(t))
+}
+
+// Draw the maze seen from the camera.
+func (fx *Fx) Draw(cam Camera) image.Image {
+	dirX, dirY := math.Cos(cam.Heading), math.Sin(cam.Heading)
+	// The camera plane is perpendicular to the direction.
+	p := math.Tan(fx.cfg.FOV / 2)
+	planeX, planeY := -dirY*p, dirX*p
+	fx.drawFloor(cam, dirX, dirY, planeX, planeY)
+	fx.drawWalls(cam, dirX, dirY, planeX, planeY)
+	return fx.draw
+}
+
+// drawFloor draws floor and ceiling, one line at the time.
+func (fx *Fx) drawFloor(cam Camera, dirX, dirY, planeX, planeY float64) {
+	w, h := fx.w, fx.h
+	// Ray directions at the left and right edge of the screen.
+	leftX, leftY := dirX-planeX, dirY-planeY
+	rightX, rightY := dirX+planeX, dirY+planeY
+	// Camera height above the floor in screen pixels.
+	posZ := float64(w) / 2 / math.Tan(fx.cfg.FOV/2) / 2
+	floor, ceil := &fx.tex[fx.cfg.Floor], &fx.tex[fx.cfg.Ceiling]
+
+	// Pixel centers are at y + 0.5.
+	for y := (h + 1) / 2; y < h; y++ {
+		dist := posZ / (float64(y) + 0.5 - float64(h)/2)
+		shade := &fx.shade[fx.level(dist)]
+
+		// Floor position of the left pixel and the step per pixel, in 16.16 cells.
+		fx0 := int64((cam.X + dist*leftX) * 65536)
+		fy0 := int64((cam.Y + dist*leftY) * 65536)
+		sx := int64(dist * (rightX - leftX) / float64(w) * 65536)
+		sy := int64(dist * (rightY - leftY) / float64(w) * 65536)
+
+		fl := fx.lines[y]
+		// The ceiling is mirrored around the horizon.
+		cl := fx.lines[h-1-y]
+		px, py := fx0, fy0
+		for x := range fl {
+			// Position within the cell, scaled to the texture size.
+			fu := int((px&0xffff)*int64(floor.w)>>16) & floor.maskX
+			fv := int((py&0xffff)*int64(floor.h)>>16) & floor.maskY
+			fl[x] = shade[floor.pix[fv*floor.stride+fu]]
+			cu := int((px&0xffff)*int64(ceil.w)>>16) & ceil.maskX
+			cv := int((py&0xffff)*int64(ceil.h)>>16) & ceil.maskY
+			cl[x] = shade[ceil.pix[cv*ceil.stride+cu]]
+			px += sx
+			py += sy
+		}
+	}
+	if h&1 == 1 {
+		// The horizon line, which is infinitely far away.
+		mid := fx.lines[h/2]
+		c := fx.shade[shadeLevels-1][0]
+		for x := range mid {
+			mid[x] = c
+		}
+	}
+}
+
+// drawWalls casts a ray for each column and draws the wall it hits.
+func (fx *Fx) drawWalls(cam Camera, dirX, dirY, planeX, planeY float64) {
+	w, h := fx.w, fx.h
+	grid := fx.cfg.Map
+	// Wall height for distance 1, matching the floor.
+	wallH := float64(w) / 2 / math.Tan(fx.cfg.FOV/2)
+	for x := 0; x < w; x++ {
+		// Position on the camera plane, -1 -> 1.
+		camX := 2*float64(x)/float64(w) - 1
+		rayX, rayY := dirX+planeX*camX, dirY+planeY*camX
+
+		mapX, mapY := int(math.Floor(cam.X)), int(math.Floor(cam.Y))
+		// Distance along the ray to cross a cell in x and y.
+		deltaX, deltaY := math.Abs(1/rayX), math.Abs(1/rayY)
+		var stepX, stepY int
+		var sideX, sideY float64
+		if rayX < 0 {
+			stepX, sideX = -1, (cam.X-float64(mapX))*deltaX
+		} else {
+			stepX, sideX = 1, (float64(mapX)+1-cam.X)*deltaX
+		}
+		if rayY < 0 {
+			stepY, sideY = -1, (cam.Y-float64(mapY))*deltaY
+		} else {
+			stepY, sideY = 1, (float64(mapY)+1-cam.Y)*deltaY
+		}
+
+		// Step through the grid until a wall is hit.
+		var cell uint8
+		var ySide bool
+		for steps := 0; steps < 1024; steps++ {
+			if sideX < sideY {
+				sideX += deltaX
+				mapX += stepX
+				ySide = false
+			} else {
+				sideY += deltaY
+				mapY += stepY
+				ySide = true
+			}
+			if mapY < 0 || mapY >= len(grid) || mapX < 0 || mapX >= len(grid[mapY]) {
+				cell = 1
+				break
+			}
+			if cell = grid[mapY][mapX]; cell != 0 {
+				break
+			}
+		}
+		if cell == 0 {
+			continue
+		}
+
+		// Distance perpendicular to the camera plane, so walls aren't bent,
+		// and where the wall was hit, 0 -> 1.
+		var dist, hit float64
+		if ySide {
+			dist = sideY - deltaY
+			hit = cam.X + dist*rayX
+		} else {
+			dist = sideX - deltaX
+			hit = cam.Y + dist*rayY
+		}
+		hit -= math.Floor(hit)
+		if dist < 1e-3 {
+			dist = 1e-3
+		}
+
+		t := &fx.tex[cell-1]
+		u := int(hit*float64(t.w)) & t.maskX
+		// Mirror, so textures aren't flipped on opposite walls.
+		if (!ySide && rayX < 0) || (ySide && rayY > 0) {
+			u = t.maskX - u
+		}
+		l := fx.level(dist)
+		// Walls facing y are darker, which makes corners stand out.
+		if ySide {
+			l += 2
+			if l >= shadeLevels {
+				l = shadeLevels - 1
+			}
+		}
+		shade := &fx.shade[l]
+
+		// Wall column, with texture v as 16.16.
+		lineH := wallH / dist
+		top := float64(h)/2 - lineH/2
+		step := int64(float64(t.h) / lineH * 65536)
+		y0, y1 := int(math.Ceil(top)), int(math.Ceil(top+lineH))
+		v := int64((float64(y0) - top) * float64(t.h) / lineH * 65536)
+		if y0 < 0 {
+			v += int64(-y0) * step
+			y0 = 0
+		}
+		if y1 > h {
+			y1 = h
+		}
+		for y := y0; y < y1; y++ {
+			ty := int(v>>16) & t.maskY
+			fx.lines[y][x] = shade[t.pix[ty*t.stride+u]]
+			v += step
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	_ "image/png"
+	"math"
+	"time"
+
+	_ "github.com/klauspost/gad/ep02/data" // Load data.
+	"github.com/klauspost/gad/ripple"
+	"github.com/klauspost/gad/tick"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+	// Length of the loop in seconds.
+	loop = 10
+)
+
+func main() {
+	img, err := gfx.LoadPalPicture("data/ballet-egon-256.png")
+	if err != nil {
+		panic(err)
+	}
+	fx, err := ripple.New(renderWidth, renderHeight, img, ripple.Config{
+		Drops: ripple.Rain(1, 40, renderWidth, renderHeight, 1),
+		// A boat going around in a circle.
+		Wake:         wake,
+		WakeRadius:   3,
+		WakeStrength: 96,
+		Clock:        tick.Clock{Rate: 60, Duration: loop},
+		// Calmer water with stronger refraction:
+		//Damping:    6,
+		//Refraction: 24,
+	})
+	if err != nil {
+		panic(err)
+	}
+	gfx.Run(func() { gfx.RunTimedDur(fx, loop*time.Second) })
+}
+
+// wake returns the position of the boat at time t.
+func wake(t float64) (x, y float64) {
+	a := t * 2 * math.Pi
+	return renderWidth/2 + 200*math.Sin(a), renderHeight/2 + 110*math.Cos(a)
+}
@@ -0,0 +1,238 @@
+// Package ripple simulates water waves refracting a background image.
+//
+// The water height is kept in two buffers, the current and the previous step.
+// Each step the new height is the average of the neighbors minus
+// the previous height, which makes waves spread out, and a small
+// part is removed so waves die out.
+// The background is drawn offset by the slope of the water.
+package ripple
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/klauspost/gad/rng"
+	"github.com/klauspost/gad/tick"
+)
+
+// Drop is a drop falling into the water.
+type Drop struct {
+	// T is the time of the drop.
+	T float64
+	// Position in pixels.
+	X, Y int
+	// Radius in pixels.
+	Radius int
+	// Strength is the depth of the drop. 512 is a medium sized drop.
+	Strength int
+}
+
+// Config describes the water.
+type Config struct {
+	// Drops are made at their time.
+	Drops []Drop
+
+	// Wake returns the position of a point moving through the water at time t,
+	// which leaves a wake. If nil there is no wake.
+	Wake func(t float64) (x, y float64)
+
+	// Radius and strength of the wake, added every step.
+	WakeRadius, WakeStrength int
+
+	// Damping removes 1 / 2^Damping of the height per step. 0 gives 5.
+	Damping uint
+
+	// Refraction is the offset in pixels for a slope of 256. 0 gives 16.
+	Refraction int
+
+	// Clock decides the number of steps for each frame.
+	// Set Rate and Duration, so drops happen at the same place in every loop.
+	Clock tick.Clock
+}
+
+// Fx is a water effect.
+type Fx struct {
+	cfg Config
+	// Current and previous height.
+	cur, prev []int32
+	bg        *image.Paletted
+	maskX     int
+	maskY     int
+	draw      *image.Paletted
+	lines     [][]byte
+	w, h      int
+	// The time the simulation has reached.
+	// Drops after this time haven't been made.
+	simT float64
+}
+
+// New returns water with the background bg, rendering w * h pixels.
+// The background is repeated, so its size must be powers of two.
+func New(w, h int, bg *image.Paletted, c Config) (*Fx, error) {
+	if w < 3 || h < 3 {
+		return nil, errors.New("ripple: invalid size")
+	}
+	bw, bh := bg.Rect.Dx(), bg.Rect.Dy()
+	if bw <= 0 || bh <= 0 || bw&(bw-1) != 0 || bh&(bh-1) != 0 {
+		return nil, errors.New("ripple: background size is not power of two")
+	}
+	if c.Damping == 0 {
+		c.Damping = 5
+	}
+	if c.Refraction == 0 {
+		c.Refraction = 16
+	}
+	fx := Fx{
+		cfg:   c,
+		cur:   make([]int32, w*h),
+		prev:  make([]int32, w*h),
+		bg:    bg,
+		maskX: bw - 1,
+		maskY: bh - 1,
+		w:     w,
+		h:     h,
+		simT:  math.Inf(-1),
+	}
+
+	// Create our draw buffer
+	fx.draw = image.NewPaletted(image.Rect(0, 0, w, h), bg.Palette)
+
+	// Store each line as a slice in a slice.
+	fx.lines = make([][]byte, h)
+	for y := range fx.lines {
+		fx.lines[y] = fx.draw.Pix[y*fx.draw.Stride : y*fx.draw.Stride+w]
+	}
+	return &fx, nil
+}
+
+// Rain returns n random drops for a w * h area,
+// spread over the time from 0 to duration.
+func Rain(seed int64, n, w, h int, duration float64) []Drop {
+	rnd := rng.New(seed)
+	drops := make([]Drop, n)
+	for i := range drops {
+		drops[i] = Drop{
+			T:        rnd.Float64() * duration,
+			X:        rnd.Intn(w),
+			Y:        rnd.Intn(h),
+			Radius:   2 + rnd.Intn(5),
+			Strength: 256 + rnd.Intn(512),
+		}
+	}
+	return drops
+}
+
+// Drop makes a drop at (x, y) now.
+func (fx *Fx) Drop(x, y, radius, strength int) {
+	if radius < 1 {
+		radius = 1
+	}
+	for dy := -radius; dy <= radius; dy++ {
+		py := y + dy
+		// Keep the border at 0.
+		if py < 1 || py >= fx.h-1 {
+			continue
+		}
+		for dx := -radius; dx <= radius; dx++ {
+			px := x + dx
+			if px < 1 || px >= fx.w-1 {
+				continue
+			}
+			d := math.Sqrt(float64(dx*dx+dy*dy)) / float64(radius)
+			if d >= 1 {
+				continue
+			}
+			// Round drop, deepest in the center.
+			fx.cur[py*fx.w+px] -= int32(float64(strength) * (math.Cos(d*math.Pi) + 1) / 2)
+		}
+	}
+}
+
+// Reset removes all waves.
+func (fx *Fx) Reset() {
+	for i := range fx.cur {
+		fx.cur[i] = 0
+		fx.prev[i] = 0
+	}
+}
+
+// Step moves the waves one step.
+func (fx *Fx) Step() {
+	w := fx.w
+	damp := fx.cfg.Damping
+	// Write the new height over the previous, which isn't needed anymore.
+	cur, next := fx.cur, fx.prev
+	for y := 1; y < fx.h-1; y++ {
+		row := y * w
+		for x := row + 1; x < row+w-1; x++ {
+			v := (cur[x-1]+cur[x+1]+cur[x-w]+cur[x+w])>>1 - next[x]
+			next[x] = v - v>>damp
+		}
+	}
+	fx.cur, fx.prev = next, cur
+}
+
+// Render the effect at time t.
+// Steps are made to keep up with the rate and drops are made when their time is reached.
+// When the effect loops, the water is reset and stepped from the start,
+// so scripted drops are repeated.
+func (fx *Fx) Render(t float64) image.Image {
+	from := fx.cfg.Clock.Last()
+	n, looped := fx.cfg.Clock.Advance(t)
+	if looped {
+		fx.Reset()
+		fx.simT = math.Inf(-1)
+		fx.cfg.Clock.Reset(0)
+		from = 0
+		n, _ = fx.cfg.Clock.Advance(t)
+	}
+	for i := 0; i < n; i++ {
+		// Time of this step, so drops and the wake are spread over the steps.
+		st := from + (t-from)*float64(i+1)/float64(n)
+		for _, d := range fx.cfg.Drops {
+			if d.T > fx.simT && d.T <= st {
+				fx.Drop(d.X, d.Y, d.Radius, d.Strength)
+			}
+		}
+		if fx.cfg.Wake != nil {
+			x, y := fx.cfg.Wake(st)
+			fx.Drop(int(x), int(y), fx.cfg.WakeRadius, fx.cfg.WakeStrength)
+		}
+		fx.simT = st
+		fx.Step()
+	}
+	fx.refract()
+	return fx.draw
+}
+
+// refract draws the background offset by the slope of the water.
+func (fx *Fx) refract() {
+	w, h := fx.w, fx.h
+	bg, stride := fx.bg.Pix, fx.bg.Stride
+	refr := int32(fx.cfg.Refraction)
+	for y, line := range fx.lines {
+		row := y * w
+		up, down := row-w, row+w
+		if y == 0 {
+			up = row
+		}
+		if y == h-1 {
+			down = row
+		}
+		for x := range line {
+			l, r := x-1, x+1
+			if x == 0 {
+				l = x
+			}
+			if x == w-1 {
+				r = x
+			}
+			dx := (fx.cur[row+l] - fx.cur[row+r]) * refr >> 8
+			dy := (fx.cur[up+x] - fx.cur[down+x]) * refr >> 8
+			sx := (x + int(dx)) & fx.maskX
+			sy := (y + int(dy)) & fx.maskY
+			line[x] = bg[sy*stride+sx]
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package rng
+
+// Hash returns a random value for a seed and a position in space and time.
+// The same input always returns the same value, so it can be used
+// for per pixel and per frame noise without keeping state.
+func Hash(s uint32, x, y, t int32) uint32 {
+	if override.set {
+		s ^= uint32(override.seed)
+	}
+	h := (s + 0x9e3779b9) ^ uint32(x)*0x8da6b343 ^ uint32(y)*0xd8163841 ^ uint32(t)*0xcb1ab31f
+	h ^= h >> 15
+	h *= 0x2c1b3c6d
+	h ^= h >> 12
+	h *= 0x297a2d39
+	h ^= h >> 15
+	return h
+}
+
+// HashFloat returns Hash as a value in the range 0 -> 1, excluding 1.
+func HashFloat(s uint32, x, y, t int32) float32 {
+	return float32(Hash(s, x, y, t)>>8) * (1.0 / (1 << 24))
+}
+
+// Xorshift is a 32 bit xorshift generator for inner loops.
+// It is smaller and faster than Rand, but has lower quality.
+type Xorshift uint32
+
+// NewXorshift returns a generator with the given seed.
+// The seed is mixed, so similar seeds give unrelated sequences.
+func NewXorshift(s uint32) Xorshift {
+	x := Xorshift(Hash(s, 0, 0, 0))
+	if x == 0 {
+		// The state must never be 0.
+		x = 0x9e3779b9
+	}
+	return x
+}
+
+// Next returns the next random value.
+func (x *Xorshift) Next() uint32 {
+	v := uint32(*x)
+	v ^= v << 13
+	v ^= v >> 17
+	v ^= v << 5
+	*x = Xorshift(v)
+	return v
+}
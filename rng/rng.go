@@ -0,0 +1,158 @@
+// Package rng contains small, fast and deterministic random generators
+// shared by all effects, so renders can be reproduced exactly.
+//
+// Rand is a PCG generator for general use, Xorshift is a minimal generator
+// for inner loops and Hash returns random values from coordinates
+// without keeping any state.
+//
+// SetSeed changes the seeds of everything in the package,
+// so all effects can be varied, or locked, from one place.
+package rng
+
+import "math"
+
+// override is mixed into all seeds if set.
+var override struct {
+	set  bool
+	seed uint64
+}
+
+// SetSeed overrides the seed of all generators created after the call and of Hash.
+// The seed is mixed with the seed requested by each effect,
+// so they still get separate sequences.
+// It must be called before effects are created and is not safe for concurrent use.
+func SetSeed(seed int64) {
+	override.set = true
+	override.seed = mix64(uint64(seed))
+}
+
+// ResetSeed removes the seed override.
+func ResetSeed() {
+	override.set = false
+	override.seed = 0
+}
+
+// seed returns seed mixed with the override.
+func seed(s uint64) uint64 {
+	if !override.set {
+		return s
+	}
+	return mix64(s ^ override.seed)
+}
+
+// mix64 is the SplitMix64 finalizer.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Rand is a PCG32 random generator.
+// It is not safe for concurrent use; use Split to give each goroutine its own.
+type Rand struct {
+	state, inc uint64
+}
+
+const pcgMul = 6364136223846793005
+
+// New returns a generator with the given seed.
+func New(s int64) *Rand {
+	return NewStream(s, 0)
+}
+
+// NewStream returns a generator with the given seed and stream.
+// Generators with the same seed and different streams give unrelated sequences.
+func NewStream(s int64, stream uint64) *Rand {
+	var r Rand
+	r.init(seed(uint64(s)), stream)
+	return &r
+}
+
+func (r *Rand) init(s, stream uint64) {
+	// The increment must be odd.
+	r.inc = stream<<1 | 1
+	r.state = 0
+	r.Uint32()
+	r.state += s
+	r.Uint32()
+}
+
+// Seed resets the generator to the given seed on stream 0.
+// Together with Int63 and Uint64 it implements math/rand.Source64.
+func (r *Rand) Seed(s int64) {
+	r.init(seed(uint64(s)), 0)
+}
+
+// Split returns a new generator on a separate stream.
+// The sequence of r is advanced.
+func (r *Rand) Split() *Rand {
+	var n Rand
+	n.init(r.Uint64(), r.Uint64())
+	return &n
+}
+
+// Uint32 returns a random 32 bit value.
+func (r *Rand) Uint32() uint32 {
+	old := r.state
+	r.state = old*pcgMul + r.inc
+	xs := uint32(((old >> 18) ^ old) >> 27)
+	rot := uint32(old >> 59)
+	return xs>>rot | xs<<((-rot)&31)
+}
+
+// Uint64 returns a random 64 bit value.
+func (r *Rand) Uint64() uint64 {
+	return uint64(r.Uint32())<<32 | uint64(r.Uint32())
+}
+
+// Int63 returns a random non-negative 63 bit value.
+func (r *Rand) Int63() int64 {
+	return int64(r.Uint64() >> 1)
+}
+
+// Int63n returns a random value 0 -> n-1. n must be > 0.
+func (r *Rand) Int63n(n int64) int64 {
+	if n <= 0 {
+		panic("rng: invalid argument to Int63n")
+	}
+	// Reject values above the largest multiple of n to avoid bias.
+	max := int64((1<<63 - 1) - (1<<63)%uint64(n))
+	v := r.Int63()
+	for v > max {
+		v = r.Int63()
+	}
+	return v % n
+}
+
+// Intn returns a random value 0 -> n-1. n must be > 0.
+func (r *Rand) Intn(n int) int {
+	if n <= 0 {
+		panic("rng: invalid argument to Intn")
+	}
+	if uint64(n) <= math.MaxUint32 {
+		// Lemire's multiply and shift, without the rare rejection.
+		return int(uint64(r.Uint32()) * uint64(n) >> 32)
+	}
+	return int(r.Int63n(int64(n)))
+}
+
+// Float32 returns a random value in the range 0 -> 1, excluding 1.
+func (r *Rand) Float32() float32 {
+	return float32(r.Uint32()>>8) * (1.0 / (1 << 24))
+}
+
+// Float64 returns a random value in the range 0 -> 1, excluding 1.
+func (r *Rand) Float64() float64 {
+	return float64(r.Uint64()>>11) * (1.0 / (1 << 53))
+}
+
+// Perm returns a random permutation of 0 -> n-1.
+func (r *Rand) Perm(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		j := r.Intn(i + 1)
+		p[i] = p[j]
+		p[j] = i
+	}
+	return p
+}
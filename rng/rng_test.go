@@ -0,0 +1,227 @@
+package rng
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRandVector(t *testing.T) {
+	// Output of the pcg32 reference implementation for seed 42, stream 54.
+	r := NewStream(42, 54)
+	want := []uint32{0xa15c02b7, 0x7b47f409, 0xba1d3330, 0x83d2f293, 0xbfa4784b, 0xcbed606e}
+	for i, v := range want {
+		if got := r.Uint32(); got != v {
+			t.Errorf("value %d: got %#x, want %#x", i, got, v)
+		}
+	}
+	r = New(1)
+	want = []uint32{0xe2393051, 0x01112f35, 0xd3509d35, 0x0b932f4a}
+	for i, v := range want {
+		if got := r.Uint32(); got != v {
+			t.Errorf("seed 1 value %d: got %#x, want %#x", i, got, v)
+		}
+	}
+}
+
+func TestXorshiftVector(t *testing.T) {
+	// Marsaglia's xorshift32 with shifts 13, 17, 5, starting at 1.
+	x := Xorshift(1)
+	want := []uint32{270369, 67634689, 2647435461, 307599695, 2398689233}
+	for i, v := range want {
+		if got := x.Next(); got != v {
+			t.Errorf("value %d: got %d, want %d", i, got, v)
+		}
+	}
+	x = NewXorshift(1)
+	want = []uint32{0x9a48ade0, 0x7169a95a, 0xcc1ba41b, 0xab8b92b7}
+	for i, v := range want {
+		if got := x.Next(); got != v {
+			t.Errorf("seed 1 value %d: got %#x, want %#x", i, got, v)
+		}
+	}
+}
+
+func TestHashVector(t *testing.T) {
+	tests := []struct {
+		s       uint32
+		x, y, t int32
+		want    uint32
+	}{
+		{0, 0, 0, 0, 0x3dab4527},
+		{1, 2, 3, 4, 0x1c043df4},
+		{1, -2, 3, 4, 0x29ea0a8c},
+		{0xffffffff, 100, -100, 7, 0xfee84afb},
+	}
+	for _, v := range tests {
+		if got := Hash(v.s, v.x, v.y, v.t); got != v.want {
+			t.Errorf("Hash(%d, %d, %d, %d): got %#x, want %#x", v.s, v.x, v.y, v.t, got, v.want)
+		}
+		if f := HashFloat(v.s, v.x, v.y, v.t); f < 0 || f >= 1 {
+			t.Errorf("HashFloat(%d, %d, %d, %d): %v out of range", v.s, v.x, v.y, v.t, f)
+		}
+	}
+}
+
+// correlation returns the correlation of Float64 values from a and b.
+func correlation(a, b *Rand, n int) float64 {
+	var sa, sb, sab, saa, sbb float64
+	for i := 0; i < n; i++ {
+		x, y := a.Float64(), b.Float64()
+		sa += x
+		sb += y
+		sab += x * y
+		saa += x * x
+		sbb += y * y
+	}
+	fn := float64(n)
+	cov := sab/fn - sa/fn*sb/fn
+	return cov / math.Sqrt((saa/fn-sa*sa/fn/fn)*(sbb/fn-sb*sb/fn/fn))
+}
+
+func TestSplit(t *testing.T) {
+	const n = 10000
+	parent := New(7)
+	a := parent.Split()
+	b := parent.Split()
+
+	// No stream may repeat another, even shifted.
+	seqs := map[string][]uint32{}
+	for name, r := range map[string]*Rand{"parent": New(7), "a": a, "b": b} {
+		s := make([]uint32, n)
+		for i := range s {
+			s[i] = r.Uint32()
+		}
+		seqs[name] = s
+	}
+	for na, sa := range seqs {
+		start := map[[2]uint32]bool{}
+		for i := 0; i+1 < len(sa); i++ {
+			start[[2]uint32{sa[i], sa[i+1]}] = true
+		}
+		for nb, sb := range seqs {
+			if na == nb {
+				continue
+			}
+			if start[[2]uint32{sb[0], sb[1]}] {
+				t.Errorf("%s contains the start of %s", na, nb)
+			}
+		}
+	}
+
+	// Splitting is deterministic.
+	if got, want := New(7).Split().Uint32(), seqs["a"][0]; got != want {
+		t.Errorf("split of same seed: got %#x, want %#x", got, want)
+	}
+
+	parent = New(7)
+	a, b = parent.Split(), parent.Split()
+	for name, c := range map[string]float64{
+		"a/b":      correlation(a, b, n),
+		"a/parent": correlation(a, parent, n),
+	} {
+		if math.Abs(c) > 0.05 {
+			t.Errorf("%s: correlation %.3f", name, c)
+		}
+	}
+}
+
+func TestIntn(t *testing.T) {
+	r := New(3)
+	for _, n := range []int{1, 2, 3, 10, 1000, 1 << 40} {
+		for i := 0; i < 1000; i++ {
+			if v := r.Intn(n); v < 0 || v >= n {
+				t.Fatalf("Intn(%d): got %d", n, v)
+			}
+		}
+	}
+	// All values of a small range must appear.
+	var seen [7]int
+	for i := 0; i < 7000; i++ {
+		seen[r.Intn(7)]++
+	}
+	for v, c := range seen {
+		if c < 800 || c > 1200 {
+			t.Errorf("Intn(7): value %d seen %d times of 7000", v, c)
+		}
+	}
+	for _, n := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Intn(%d) should panic", n)
+				}
+			}()
+			r.Intn(n)
+		}()
+	}
+}
+
+func TestPerm(t *testing.T) {
+	r := New(4)
+	for _, n := range []int{0, 1, 2, 10, 100} {
+		p := r.Perm(n)
+		if len(p) != n {
+			t.Fatalf("Perm(%d): got length %d", n, len(p))
+		}
+		seen := make([]bool, n)
+		for _, v := range p {
+			if v < 0 || v >= n || seen[v] {
+				t.Fatalf("Perm(%d): not a permutation: %v", n, p)
+			}
+			seen[v] = true
+		}
+	}
+	if a, b := New(4).Perm(20), New(4).Perm(20); !equal(a, b) {
+		t.Errorf("Perm with same seed differs: %v, %v", a, b)
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSetSeed(t *testing.T) {
+	defer ResetSeed()
+	plainRand := New(1).Uint64()
+	plainHash := Hash(1, 2, 3, 4)
+	plainXor := NewXorshift(1)
+
+	SetSeed(99)
+	r := New(1).Uint64()
+	if r == plainRand {
+		t.Error("SetSeed did not change Rand")
+	}
+	if Hash(1, 2, 3, 4) == plainHash {
+		t.Error("SetSeed did not change Hash")
+	}
+	if NewXorshift(1) == plainXor {
+		t.Error("SetSeed did not change Xorshift")
+	}
+	if New(2).Uint64() == r {
+		t.Error("seeds are not kept separate with an override")
+	}
+	SetSeed(99)
+	if got := New(1).Uint64(); got != r {
+		t.Errorf("same override: got %#x, want %#x", got, r)
+	}
+	SetSeed(100)
+	if New(1).Uint64() == r {
+		t.Error("different overrides give the same sequence")
+	}
+
+	ResetSeed()
+	if got := New(1).Uint64(); got != plainRand {
+		t.Errorf("after ResetSeed: got %#x, want %#x", got, plainRand)
+	}
+	if got := Hash(1, 2, 3, 4); got != plainHash {
+		t.Errorf("Hash after ResetSeed: got %#x, want %#x", got, plainHash)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"image/color"
+	_ "image/png"
+	"math"
+
+	"github.com/klauspost/gad/copper"
+	_ "github.com/klauspost/gad/dentro/data" // Load font.
+	"github.com/klauspost/gad/dentro/screen"
+	"github.com/klauspost/gad/scroller"
+	"github.com/klauspost/gad/texture"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+)
+
+const text = "GREETINGS FROM GO AFTER DARK... DIFFERENT Y CHARACTER POSITIONS, " +
+	"JUST LIKE ON THE C64... WRAP!"
+
+func main() {
+	font, err := screen.LoadFont("data/dosfont.png")
+	if err != nil {
+		panic(err)
+	}
+	scroll, err := scroller.New(renderWidth, renderHeight, font, scroller.Config{
+		Text:  text,
+		Scale: 3,
+		Amp:   100,
+		Freq:  1.5,
+		Wave:  4,
+		Style: rainbow,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Show the scroller in front of copper bars.
+	c, err := copper.New(renderWidth, renderHeight, texture.RainbowPalette)
+	if err != nil {
+		panic(err)
+	}
+	fx := c.Effect(scroll, func(c *copper.Fx, t float64) {
+		c.Reset()
+		c.Sky(color.RGBA{B: 30, A: 255}, color.RGBA{R: 30, B: 60, A: 255}, 0)
+		c.SineBars(t, 0,
+			copper.Bar{Color: color.RGBA{R: 200, G: 200, B: 220, A: 255}, Height: 16, Amp: 150, Freq: 2},
+			copper.Bar{Color: color.RGBA{R: 140, G: 140, B: 160, A: 255}, Height: 16, Amp: 150, Freq: 2, Phase: 0.5},
+		)
+	})
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
+
+// rainbow colors each character and makes it pulse.
+// Colors cycle 8 times and characters pulse 5 times per loop,
+// so the last frame matches the first.
+func rainbow(t float64, c *scroller.Char) {
+	c.Color = uint8(1 + (c.Index*12+int(t*255*8))%255)
+	c.Scale *= 1 + 0.25*math.Sin(c.X/50+t*2*math.Pi*5)
+}
@@ -0,0 +1,191 @@
+// Package scroller renders a text scrolling across the screen,
+// with each character moving up and down on a sine (DYCP).
+//
+// Characters use the 8x16 font of dentro/screen and are scaled
+// with nearest neighbor, so each character can have its own size and color.
+// The scroller can render to its own image or be drawn on top of another effect.
+package scroller
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/klauspost/gad/dentro/screen"
+)
+
+// Char is a character about to be drawn.
+type Char struct {
+	// Index of the character in the text.
+	Index int
+
+	// Center of the character on screen.
+	X, Y float64
+
+	// Scale of the character. 1 is 8x16 pixels.
+	Scale float64
+
+	// Color of the set pixels.
+	Color uint8
+}
+
+// Config describes a scroller.
+type Config struct {
+	Text string
+
+	// Speed is the number of pixels scrolled per unit of time.
+	// If 0, the text scrolls across once per unit of time, so it loops.
+	Speed float64
+
+	// Scale of the characters. 0 gives 2.
+	Scale float64
+
+	// Color of the characters. 0 gives 255.
+	Color uint8
+
+	// Background fills the image before drawing, when using Render.
+	Background uint8
+
+	// Y is the center of the sine, relative to the screen center.
+	Y float64
+
+	// Amp is the amplitude of the sine in pixels.
+	Amp float64
+
+	// Freq is the number of periods across the screen.
+	Freq float64
+
+	// Wave is the number of periods the sine moves sideways per unit of time.
+	Wave float64
+
+	// Style can change each character before it is drawn.
+	// If nil, characters are drawn as set above.
+	Style func(t float64, c *Char)
+}
+
+// Fx is a scroller.
+type Fx struct {
+	cfg  Config
+	font *screen.Font
+	draw *image.Gray
+	w, h int
+}
+
+// New returns a scroller for a w * h screen using font.
+// Use screen.LoadFont or the font of a screen.Fx.
+func New(w, h int, font *screen.Font, c Config) (*Fx, error) {
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("scroller: invalid size")
+	}
+	if font == nil {
+		return nil, errors.New("scroller: no font")
+	}
+	if c.Scale == 0 {
+		c.Scale = 2
+	}
+	if c.Color == 0 {
+		c.Color = 255
+	}
+	fx := Fx{cfg: c, font: font, w: w, h: h}
+
+	// Create our draw buffer
+	fx.draw = image.NewGray(image.Rect(0, 0, w, h))
+	return &fx, nil
+}
+
+// Render the effect at time t on the background color.
+func (fx *Fx) Render(t float64) image.Image {
+	bg := fx.cfg.Background
+	for i := range fx.draw.Pix {
+		fx.draw.Pix[i] = bg
+	}
+	fx.Draw(fx.draw, t)
+	return fx.draw
+}
+
+// Draw the text at time t on top of dst.
+// *image.Gray and *image.Paletted are drawn directly, with the color
+// as grey value or palette index.
+func (fx *Fx) Draw(dst draw.Image, t float64) {
+	var set func(x, y int, v uint8)
+	r := dst.Bounds()
+	switch img := dst.(type) {
+	case *image.Gray:
+		set = func(x, y int, v uint8) { img.Pix[(y-r.Min.Y)*img.Stride+x-r.Min.X] = v }
+	case *image.Paletted:
+		set = func(x, y int, v uint8) { img.Pix[(y-r.Min.Y)*img.Stride+x-r.Min.X] = v }
+	default:
+		set = func(x, y int, v uint8) { img.Set(x, y, color.Gray{Y: v}) }
+	}
+
+	cfg := &fx.cfg
+	adv := 8 * cfg.Scale
+	total := float64(len(cfg.Text))*adv + float64(fx.w)
+	pos := t * cfg.Speed
+	if cfg.Speed == 0 {
+		pos = t * total
+	}
+	pos = math.Mod(pos, total)
+	if pos < 0 {
+		pos += total
+	}
+	for i := 0; i < len(cfg.Text); i++ {
+		ch := cfg.Text[i]
+		c := Char{
+			Index: i,
+			X:     float64(fx.w) - pos + (float64(i)+0.5)*adv,
+			Scale: cfg.Scale,
+			Color: cfg.Color,
+		}
+		// Skip characters far outside the screen.
+		if c.X < -2*adv || c.X > float64(fx.w)+2*adv {
+			continue
+		}
+		a := (cfg.Freq*c.X/float64(fx.w) + cfg.Wave*t) * 2 * math.Pi
+		c.Y = float64(fx.h)/2 + cfg.Y + cfg.Amp*math.Sin(a)
+		if cfg.Style != nil {
+			cfg.Style(t, &c)
+		}
+		if ch == ' ' || c.Scale <= 0 {
+			continue
+		}
+		drawChar(set, r, &fx.font[ch&127], c)
+	}
+}
+
+// drawChar draws a single character.
+func drawChar(set func(x, y int, v uint8), r image.Rectangle, glyph *[16]byte, c Char) {
+	w, h := 8*c.Scale, 16*c.Scale
+	x0 := int(math.Floor(c.X - w/2))
+	y0 := int(math.Floor(c.Y - h/2))
+	x1, y1 := x0+int(w), y0+int(h)
+	// Glyph pixels per screen pixel as 16.16.
+	step := int(65536 / c.Scale)
+	for y := y0; y < y1; y++ {
+		if y < r.Min.Y || y >= r.Max.Y {
+			continue
+		}
+		gy := ((y - y0) * step) >> 16
+		if gy > 15 {
+			gy = 15
+		}
+		row := glyph[gy]
+		if row == 0 {
+			continue
+		}
+		for x := x0; x < x1; x++ {
+			if x < r.Min.X || x >= r.Max.X {
+				continue
+			}
+			gx := uint(((x - x0) * step) >> 16)
+			if gx > 7 {
+				gx = 7
+			}
+			if row<<gx&0x80 != 0 {
+				set(x, y, c.Color)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package sprite
+
+import (
+	"errors"
+	"image"
+)
+
+// Atlas is a collection of sprites, so each particle can pick an image.
+type Atlas struct {
+	Sprites []*Sprite
+}
+
+// LoadAtlas loads a sprite from each of the files.
+func LoadAtlas(files ...string) (*Atlas, error) {
+	var a Atlas
+	for _, file := range files {
+		s, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		a.Sprites = append(a.Sprites, s)
+	}
+	return &a, nil
+}
+
+// NewAtlas splits an image into square cells of size * size pixels
+// and creates a sprite from each cell, row by row.
+// The size must be a power of two.
+func NewAtlas(img *image.Gray, size int) (*Atlas, error) {
+	w, h := img.Rect.Dx()/size, img.Rect.Dy()/size
+	if w == 0 || h == 0 {
+		return nil, errors.New("sprite: atlas image smaller than cell size")
+	}
+	var a Atlas
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Copy, so the sprite image starts at (0, 0).
+			cell := image.NewGray(image.Rect(0, 0, size, size))
+			for j := 0; j < size; j++ {
+				src := img.Pix[img.PixOffset(img.Rect.Min.X+x*size, img.Rect.Min.Y+y*size+j):]
+				copy(cell.Pix[j*cell.Stride:j*cell.Stride+size], src)
+			}
+			s, err := New(cell)
+			if err != nil {
+				return nil, err
+			}
+			a.Sprites = append(a.Sprites, s)
+		}
+	}
+	return &a, nil
+}
+
+// Pick returns a sprite from a value 0 -> 1, for example Particle.Rand.
+func (a *Atlas) Pick(f float32) *Sprite {
+	n := len(a.Sprites)
+	i := int(f * float32(n))
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return a.Sprites[i]
+}
@@ -0,0 +1,56 @@
+package sprite
+
+import (
+	"image"
+	"sort"
+)
+
+// Batch collects sprites so they can be drawn back to front.
+// This is needed for blend operations where the order matters.
+type Batch struct {
+	items []batchItem
+}
+
+type batchItem struct {
+	s             *Sprite
+	x, y, r, blur int32
+	angle, z      float32
+	b             Blend
+}
+
+// Add a sprite centered at x,y with radius r to the batch.
+// z is the depth of the sprite. Sprites with bigger z are drawn first.
+// Input is assumed to be 24.8
+func (b *Batch) Add(s *Sprite, x, y, r int32, z float32, blend Blend) {
+	b.items = append(b.items, batchItem{s: s, x: x, y: y, r: r, z: z, b: blend})
+}
+
+// AddBlurred adds a sprite to the batch that is drawn like DrawBlurred.
+// z is the depth of the sprite. Sprites with bigger z are drawn first.
+// Input is assumed to be 24.8
+func (b *Batch) AddBlurred(s *Sprite, x, y, r, blur int32, angle, z float32, blend Blend) {
+	b.items = append(b.items, batchItem{s: s, x: x, y: y, r: r, blur: blur, angle: angle, z: z, b: blend})
+}
+
+// Len returns the number of sprites in the batch.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Draw all sprites in the batch onto dst, sorted by depth.
+// Sprites with the same depth are drawn in the order they were added.
+// The batch is empty afterwards.
+func (b *Batch) Draw(dst *image.Gray, mode Mode) {
+	items := b.items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].z > items[j].z
+	})
+	for _, it := range items {
+		if it.blur <= 0 && it.angle == 0 {
+			it.s.DrawBlend(dst, it.x, it.y, it.r, mode, it.b)
+			continue
+		}
+		it.s.DrawBlurred(dst, it.x, it.y, it.r, it.blur, it.angle, mode, it.b)
+	}
+	b.items = items[:0]
+}
@@ -0,0 +1,95 @@
+package sprite
+
+// Op is the operation used to combine a sprite with the destination.
+type Op uint8
+
+const (
+	// Add will add the sprite to the destination. This is the default.
+	Add Op = iota
+	// Over will draw the tint color over the destination using the sprite as alpha.
+	Over
+	// Multiply will darken the destination with the tint color using the sprite as alpha.
+	Multiply
+	// Subtract will subtract the sprite from the destination.
+	Subtract
+	// Screen will lighten the destination, but never saturate.
+	Screen
+	// Max will keep the brightest of the sprite and the destination.
+	Max
+)
+
+// Blend describes how a sprite is combined with the destination.
+// The sprite pixel values are used as coverage, which is scaled by Opacity.
+// The color of the sprite is the Tint scaled by coverage.
+type Blend struct {
+	Op Op
+	// Opacity of the sprite. 255 is fully opaque.
+	Opacity uint8
+	// Tint is the color of the sprite. 255 keeps the sprite unchanged.
+	Tint uint8
+}
+
+// Additive is the blend used by Draw.
+var Additive = Blend{Op: Add, Opacity: 255, Tint: 255}
+
+// NewBlend returns a blend with the specified operation at full opacity and no tint.
+func NewBlend(op Op) Blend {
+	return Blend{Op: op, Opacity: 255, Tint: 255}
+}
+
+// blendLine will blend src onto dst.
+// dst and src must have the same length.
+func (b Blend) blendLine(dst, src []byte) {
+	// Scale opacity and tint so 255 becomes 256.
+	opacity := uint32(b.Opacity) + uint32(b.Opacity>>7)
+	tint := uint32(b.Tint)
+	color := tint + tint>>7
+	switch b.Op {
+	case Add:
+		if b.Opacity == 255 && b.Tint == 255 {
+			for i, s := range src {
+				dst[i] = clamp8uint32(uint32(s) + uint32(dst[i]))
+			}
+			return
+		}
+		for i, s := range src {
+			c := (uint32(s) * opacity * color) >> 16
+			dst[i] = clamp8uint32(c + uint32(dst[i]))
+		}
+	case Subtract:
+		for i, s := range src {
+			c := (uint32(s) * opacity * color) >> 16
+			d := uint32(dst[i])
+			if c >= d {
+				dst[i] = 0
+				continue
+			}
+			dst[i] = uint8(d - c)
+		}
+	case Over:
+		for i, s := range src {
+			a := int32((uint32(s) * opacity) >> 8)
+			d := int32(dst[i])
+			dst[i] = uint8(d + ((int32(tint)-d)*a)/255)
+		}
+	case Multiply:
+		for i, s := range src {
+			a := (uint32(s) * opacity) >> 8
+			f := 255 - a + (a*tint)/255
+			dst[i] = uint8((uint32(dst[i]) * f) / 255)
+		}
+	case Screen:
+		for i, s := range src {
+			c := (uint32(s) * opacity * color) >> 16
+			d := uint32(dst[i])
+			dst[i] = uint8(d + c - (d*c)/255)
+		}
+	case Max:
+		for i, s := range src {
+			c := uint8((uint32(s) * opacity * color) >> 16)
+			if c > dst[i] {
+				dst[i] = c
+			}
+		}
+	}
+}
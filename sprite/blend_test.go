@@ -0,0 +1,98 @@
+package sprite
+
+import (
+	"image"
+	"testing"
+)
+
+func TestBlendLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		b        Blend
+		dst, src uint8
+		want     uint8
+	}{
+		{"add", Additive, 50, 100, 150},
+		{"add saturates", Additive, 100, 200, 255},
+		{"add opacity", Blend{Op: Add, Opacity: 128, Tint: 255}, 50, 100, 100},
+		{"add tint", Blend{Op: Add, Opacity: 255, Tint: 128}, 50, 100, 100},
+		{"add transparent", Blend{Op: Add, Opacity: 0, Tint: 255}, 50, 100, 50},
+
+		{"subtract", NewBlend(Subtract), 100, 60, 40},
+		{"subtract saturates", NewBlend(Subtract), 100, 200, 0},
+		{"subtract opacity", Blend{Op: Subtract, Opacity: 128, Tint: 255}, 100, 200, 0},
+		{"subtract tint", Blend{Op: Subtract, Opacity: 255, Tint: 64}, 100, 200, 50},
+
+		{"over full", Blend{Op: Over, Opacity: 255, Tint: 200}, 100, 255, 200},
+		{"over half", Blend{Op: Over, Opacity: 255, Tint: 200}, 100, 128, 150},
+		{"over darkens", Blend{Op: Over, Opacity: 255, Tint: 0}, 100, 255, 0},
+		{"over none", Blend{Op: Over, Opacity: 255, Tint: 200}, 100, 0, 100},
+		{"over transparent", Blend{Op: Over, Opacity: 0, Tint: 200}, 100, 255, 100},
+		{"over opacity", Blend{Op: Over, Opacity: 128, Tint: 200}, 100, 255, 150},
+
+		{"multiply white", NewBlend(Multiply), 200, 255, 200},
+		{"multiply half", Blend{Op: Multiply, Opacity: 255, Tint: 128}, 200, 255, 100},
+		{"multiply black", Blend{Op: Multiply, Opacity: 255, Tint: 0}, 200, 255, 0},
+		{"multiply coverage", Blend{Op: Multiply, Opacity: 255, Tint: 0}, 200, 128, 99},
+		{"multiply transparent", Blend{Op: Multiply, Opacity: 0, Tint: 0}, 200, 255, 200},
+
+		{"screen", NewBlend(Screen), 128, 128, 192},
+		{"screen never saturates", NewBlend(Screen), 255, 255, 255},
+		{"screen black", NewBlend(Screen), 0, 100, 100},
+		{"screen opacity", Blend{Op: Screen, Opacity: 128, Tint: 255}, 0, 200, 100},
+
+		{"max keeps dst", NewBlend(Max), 100, 50, 100},
+		{"max takes src", NewBlend(Max), 100, 150, 150},
+		{"max tint", Blend{Op: Max, Opacity: 255, Tint: 128}, 60, 200, 100},
+		{"max opacity", Blend{Op: Max, Opacity: 128, Tint: 255}, 120, 200, 120},
+	}
+	for _, test := range tests {
+		dst := []byte{test.dst}
+		test.b.blendLine(dst, []byte{test.src})
+		if dst[0] != test.want {
+			t.Errorf("%s: %d onto %d: got %d, want %d", test.name, test.src, test.dst, dst[0], test.want)
+		}
+	}
+}
+
+// solid returns a sprite that covers its whole square.
+func solid(t *testing.T) *Sprite {
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	s, err := New(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestBatchOrder(t *testing.T) {
+	s := solid(t)
+	dst := image.NewGray(image.Rect(0, 0, 16, 16))
+	center := int32(8 * 256)
+	var b Batch
+	// Added near to far, so drawing in order would leave the far color.
+	b.Add(s, center, center, 6*256, 1, Blend{Op: Over, Opacity: 255, Tint: 200})
+	b.AddBlurred(s, center, center, 6*256, 0, 0.5, 5, Blend{Op: Over, Opacity: 255, Tint: 100})
+	b.Add(s, center, center, 6*256, 10, Blend{Op: Over, Opacity: 255, Tint: 50})
+	if b.Len() != 3 {
+		t.Fatalf("got %d sprites, want 3", b.Len())
+	}
+	b.Draw(dst, Nice)
+	if got := dst.Pix[8*dst.Stride+8]; got != 200 {
+		t.Errorf("center: got %d, want the nearest sprite 200", got)
+	}
+	if b.Len() != 0 {
+		t.Errorf("batch has %d sprites after drawing", b.Len())
+	}
+
+	// Sprites at the same depth are drawn in the order they were added.
+	b.Add(s, center, center, 6*256, 1, Blend{Op: Over, Opacity: 255, Tint: 30})
+	b.Add(s, center, center, 6*256, 1, Blend{Op: Over, Opacity: 255, Tint: 60})
+	b.Draw(dst, Nice)
+	if got := dst.Pix[8*dst.Stride+8]; got != 60 {
+		t.Errorf("same depth: got %d, want the last added 60", got)
+	}
+}
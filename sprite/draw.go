@@ -0,0 +1,172 @@
+package sprite
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+)
+
+// lineFn is called with every sampled line of a sprite.
+// The line must be drawn at (x, y) in screen space.
+type lineFn func(x, y int32, row []byte)
+
+// render will sample the sprite centered at x,y with radius r
+// rotated by angle radians and send each line to fn.
+// If mip is negative, the mipmap is selected by the mode and radius.
+// Lines are clipped to a screen of width * height pixels.
+func (s *Sprite) render(width, height, x, y, r int32, angle float32, mode Mode, mip int32, fn lineFn) {
+	if mode == Fast {
+		mip = int32(len(s.Mips) - 1)
+	}
+	// Points are never rotated.
+	if angle != 0 && r > 128 {
+		m := s.calcRotated(width, height, x, y, r, angle, mip)
+		if m.mip != nil {
+			s.sampleRotated(&m, mode != Fast && mode != Mip, fn)
+		}
+		return
+	}
+	m := s.calcMapping(width, height, x, y, r, mip)
+	if m.point {
+		drawPoint(&m, width, height, fn)
+		return
+	}
+	if m.mip == nil {
+		return
+	}
+	switch mode {
+	case Fast, Mip:
+		s.sampleNearest(&m, fn)
+	default:
+		s.sampleBilinear(&m, fn)
+	}
+}
+
+// sampleNearest will sample lines with nearest neighbor scaling.
+// With the full size image as mipmap this gives the fastest result,
+// with a mipmap chosen from the radius it is also subpixel correct.
+func (s *Sprite) sampleNearest(m *mapping, fn lineFn) {
+	row := s.rowBuffer(m.endX - m.startX)
+	v := m.v0
+	for y := m.startY; y < m.endY; y++ {
+		// Line in mipmap to read from.
+		mipLine := m.mip.Pix[(v>>16)*uint32(m.mip.Stride):]
+		// Reset u
+		u := m.u0
+		for x := range row {
+			// Read from u
+			row[x] = mipLine[u>>16]
+			// Offset u for every pixel
+			u += m.uStep
+		}
+		fn(m.startX, y, row)
+		// Offset v for every line
+		v += m.vStep
+	}
+}
+
+// sampleBilinear will sample lines using bilinear interpolation.
+// The image position and size have subpixel precision.
+func (s *Sprite) sampleBilinear(m *mapping, fn lineFn) {
+	// Mipmap must be at least 2x2.
+	row := s.rowBuffer(m.endX - m.startX)
+	v := m.v0
+	for y := m.startY; y < m.endY; y++ {
+		// Input line above and below the current desired input.
+		mipLine0 := m.mip.Pix[(v>>16)*uint32(m.mip.Stride):]
+		mipLine1 := mipLine0
+
+		// Calculate weight for lines above/below desired input.
+		vf1 := (v & 0xffff) >> 8
+		vf0 := 256 - vf1
+		if (v + 65536) < m.mipSize {
+			// Set mipline 1 to next line unless last line.
+			mipLine1 = mipLine0[m.mip.Stride:]
+		}
+		u := m.u0
+		for x := range row {
+			// Calculate pixel offset before and after desired pixel.
+			xPos0 := u >> 16
+			xPos1 := xPos0
+			if u+65536 < m.mipSize {
+				xPos1++
+			}
+			// Calculate weights as fp24.8.
+			uf1 := (u & 0xffff) >> 8
+			uf0 := 256 - uf1
+			// Using the calculated weights, calculate output pixel, scaled up 16 bits.
+			pix := uint32(mipLine0[xPos0]) * uf0 * vf0
+			pix += uint32(mipLine0[xPos1]) * uf1 * vf0
+			pix += uint32(mipLine1[xPos0]) * uf0 * vf1
+			pix += uint32(mipLine1[xPos1]) * uf1 * vf1
+
+			row[x] = uint8(pix >> 16)
+			u += m.uStep
+		}
+		fn(m.startX, y, row)
+		v += m.vStep
+	}
+}
+
+// drawPoint will draw a single point between the pixel.
+func drawPoint(m *mapping, width, height int32, fn lineFn) {
+	var pix [1]byte
+	pix[0] = clamp8uint32((m.u0 * m.v0) >> 8)
+	fn(m.startX, m.startY, pix[:])
+	if m.endX < width {
+		pix[0] = clamp8uint32((m.u1 * m.v0) >> 8)
+		fn(m.endX, m.startY, pix[:])
+	}
+	if m.endY >= height {
+		return
+	}
+	pix[0] = clamp8uint32((m.u0 * m.v1) >> 8)
+	fn(m.startX, m.endY, pix[:])
+	if m.endX < width {
+		pix[0] = clamp8uint32((m.u1 * m.v1) >> 8)
+		fn(m.endX, m.endY, pix[:])
+	}
+}
+
+// drawGo will draw a sprite centered at x,y with radius r
+// using golang.org/x/image/draw. Blending is always done with draw.Over.
+func (s *Sprite) drawGo(dst *image.Gray, x, y, r int32) {
+	m := s.calcMapping(int32(dst.Rect.Dx()), int32(dst.Rect.Dy()), x, y, r, -1)
+	if m.startX == m.endX || m.startY == m.endY || m.mip == nil {
+		return
+	}
+	draw.ApproxBiLinear.Scale(dst, image.Rect(int(m.startX), int(m.startY), int(m.endX), int(m.endY)),
+		image.NewUniform(color.White), image.Rect(int(m.u0>>16), int(m.v0>>16), int(m.u1>>16), int(m.v1>>16)),
+		draw.Over, &draw.Options{
+			SrcMask: grayToShallowAlpha(m.mip),
+		})
+}
+
+// rowBuffer returns a buffer for a line of n pixels.
+func (s *Sprite) rowBuffer(n int32) []byte {
+	if n < 0 {
+		n = 0
+	}
+	if int(n) > cap(s.row) {
+		s.row = make([]byte, n)
+	}
+	return s.row[:n]
+}
+
+func clamp8uint32(v uint32) uint8 {
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// grayToShallowAlpha converts the grey image data to an alpha image.
+// The image data is a shallow (non-copy) representation of the input pixels.
+func grayToShallowAlpha(src *image.Gray) *image.Alpha {
+	return &image.Alpha{
+		Pix:    src.Pix,
+		Stride: src.Stride,
+		Rect:   src.Rect,
+	}
+}
@@ -0,0 +1,129 @@
+package sprite
+
+import (
+	"image"
+	"math/bits"
+)
+
+// mapping contains the information about the sprite needed
+// to draw it to the screen without bounds checks.
+type mapping struct {
+	// Start and end coordinates in screen space.
+	// This is where we will be drawing the pixels.
+	// This is directly translatable to a screen coordinate.
+	startX, endX, startY, endY int32
+
+	// Start and end coordinates in 16.16 fixed point coordinates on the source texture.
+	// One pixel on source is equal to 65536.
+	u0, u1, v0, v1 uint32
+
+	// Every pixel increment u and v by this when moving one pixel in screen space.
+	vStep, uStep uint32
+
+	// The size (width/height) of the chosen mip in uv scale.
+	mipSize uint32
+
+	// The image to draw from.
+	// If nil, do not draw anything.
+	mip *image.Gray
+
+	// point is set if the sprite should be drawn as a single point.
+	// u and v then contain the weight of each of the 2x2 pixels.
+	point bool
+}
+
+// calcMapping will return a mapping for a sprite with radius r placed at (x,y)
+// at the specified mip level. If mip is negative the level is chosen from the radius.
+// The mapping is clipped to a screen of width * height pixels.
+func (s *Sprite) calcMapping(width, height, x, y, r, mip int32) mapping {
+	var m mapping
+
+	// Quick discard
+	if x+r < 0 || x-r > (width*256) || y+r < 0 || y-r > (height*256) {
+		return m
+	}
+
+	// For very small radius we simply draw a point in a 2x2 square.
+	if r <= 128 {
+		m.startX, m.endX = (x-r)>>8, (x-r)>>8+1
+		m.startY, m.endY = (y-r)>>8, (y-r)>>8+1
+		if m.startX >= width || m.startX < 0 || m.startY >= height || m.startY < 0 {
+			return m
+		}
+		m.u1 = uint32(x-r) & 0xff
+		m.u0 = 256 - m.u1
+		m.v1 = uint32(y-r) & 0xff
+		m.v0 = 256 - m.v1
+
+		// Radius times 1x1 pixel value.
+		rmip := (r * int32(s.Mips[0].Pix[0])) >> 5
+		m.u0 = (m.u0 * uint32(rmip)) >> 8
+		m.u1 = (m.u1 * uint32(rmip)) >> 8
+		m.v0 = (m.v0 * uint32(rmip)) >> 8
+		m.v1 = (m.v1 * uint32(rmip)) >> 8
+		m.mipSize = 1
+		// leave mip nil
+		m.point = true
+		return m
+	}
+
+	mipLevel := mip
+	if mip < 0 {
+		mipLevel = s.mipLevel(r)
+	}
+	m.mip = s.Mips[mipLevel]
+	m.mipSize = uint32(1<<16) << uint(mipLevel)
+
+	// output pixels per texture pixels * 256
+	textureScale := float64(m.mip.Rect.Dx()) / (float64(r) / (128 * 256))
+
+	// Screen space start, rounded down
+	m.startX, m.startY = (x-r)>>8, (y-r)>>8
+	// Screen space, rounded up.
+	m.endX, m.endY = (x+r+255)>>8, (y+r+255)>>8
+
+	// Calculate rounded difference and convert to texture space.
+	m.u0, m.v0 = 255-uint32((x-r)-(m.startX<<8)), 255-uint32((y-r)-(m.startY<<8))
+	m.u0, m.v0 = uint32(float64(m.u0)*textureScale), uint32(float64(m.v0)*textureScale)
+
+	// Calculate rounded difference and convert to texture space.
+	m.u1, m.v1 = 255-uint32((m.endX<<8)-(x+r)), 255-uint32((m.endY<<8)-(y+r))
+	m.u1, m.v1 = m.mipSize-uint32(float64(m.u1)*textureScale), m.mipSize-uint32(float64(m.v1)*textureScale)
+
+	// Calculate step size per screen space pixel.
+	m.uStep = uint32(float64(m.u1-m.u0) / float64(m.endX-m.startX))
+	m.vStep = uint32(float64(m.v1-m.v0) / float64(m.endY-m.startY))
+
+	// Clip left, top, right, bottom.
+	if m.startX < 0 {
+		m.u0 += m.uStep * uint32(-m.startX)
+		m.startX = 0
+	}
+	if m.startY < 0 {
+		m.v0 += m.vStep * uint32(-m.startY)
+		m.startY = 0
+	}
+	if m.endX > width {
+		// Not needed for most
+		m.u1 -= uint32(m.endX-width) * m.uStep
+		m.endX = width
+	}
+	if m.endY > height {
+		// Not needed for most
+		m.v1 -= uint32(m.endY-height) * m.vStep
+		m.endY = height
+	}
+	return m
+}
+
+// mipLevel returns the mipmap level to use for a sprite with radius r.
+func (s *Sprite) mipLevel(r int32) int32 {
+	level := int32(bits.Len32(uint32(r>>6))) - 1
+	if int(level) >= len(s.Mips) {
+		return int32(len(s.Mips)) - 1
+	}
+	if level < 1 {
+		return 1
+	}
+	return level
+}
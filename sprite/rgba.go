@@ -0,0 +1,149 @@
+package sprite
+
+import (
+	"image/color"
+	"math"
+)
+
+// blendLineRGBA will blend the coverage in src with color c onto dst.
+// dst must contain 4 bytes for every byte in src.
+// All values are premultiplied 8 bit.
+func blendLineRGBA(dst, src []byte, op Op, c color.RGBA) {
+	cr, cg, cb, ca := uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)
+	switch op {
+	case Add:
+		for i, s := range src {
+			k := uint32(s) + uint32(s>>7)
+			d := dst[i*4 : i*4+4 : i*4+4]
+			d[0] = clamp8uint32(uint32(d[0]) + (cr*k)>>8)
+			d[1] = clamp8uint32(uint32(d[1]) + (cg*k)>>8)
+			d[2] = clamp8uint32(uint32(d[2]) + (cb*k)>>8)
+			d[3] = clamp8uint32(uint32(d[3]) + (ca*k)>>8)
+		}
+	case Subtract:
+		for i, s := range src {
+			k := uint32(s) + uint32(s>>7)
+			d := dst[i*4 : i*4+4 : i*4+4]
+			d[0] = sub8(d[0], (cr*k)>>8)
+			d[1] = sub8(d[1], (cg*k)>>8)
+			d[2] = sub8(d[2], (cb*k)>>8)
+		}
+	case Over:
+		for i, s := range src {
+			k := uint32(s) + uint32(s>>7)
+			// Inverse alpha as 0 -> 256
+			a := (ca * k) >> 8
+			inv := 256 - (a + a>>7)
+			d := dst[i*4 : i*4+4 : i*4+4]
+			d[0] = uint8((cr*k)>>8 + (uint32(d[0])*inv)>>8)
+			d[1] = uint8((cg*k)>>8 + (uint32(d[1])*inv)>>8)
+			d[2] = uint8((cb*k)>>8 + (uint32(d[2])*inv)>>8)
+			d[3] = uint8(a + (uint32(d[3])*inv)>>8)
+		}
+	case Multiply:
+		for i, s := range src {
+			k := uint32(s) + uint32(s>>7)
+			// Without coverage the destination is unchanged,
+			// with full coverage it is multiplied by the color.
+			a := 255 - (ca*k)>>8
+			d := dst[i*4 : i*4+4 : i*4+4]
+			d[0] = uint8((uint32(d[0]) * (a + (cr*k)>>8)) / 255)
+			d[1] = uint8((uint32(d[1]) * (a + (cg*k)>>8)) / 255)
+			d[2] = uint8((uint32(d[2]) * (a + (cb*k)>>8)) / 255)
+		}
+	case Screen:
+		for i, s := range src {
+			k := uint32(s) + uint32(s>>7)
+			d := dst[i*4 : i*4+4 : i*4+4]
+			d[0] = screen8(d[0], (cr*k)>>8)
+			d[1] = screen8(d[1], (cg*k)>>8)
+			d[2] = screen8(d[2], (cb*k)>>8)
+			d[3] = screen8(d[3], (ca*k)>>8)
+		}
+	case Max:
+		for i, s := range src {
+			k := uint32(s) + uint32(s>>7)
+			d := dst[i*4 : i*4+4 : i*4+4]
+			d[0] = max8(d[0], (cr*k)>>8)
+			d[1] = max8(d[1], (cg*k)>>8)
+			d[2] = max8(d[2], (cb*k)>>8)
+			d[3] = max8(d[3], (ca*k)>>8)
+		}
+	}
+}
+
+func sub8(d uint8, s uint32) uint8 {
+	if s >= uint32(d) {
+		return 0
+	}
+	return d - uint8(s)
+}
+
+func screen8(d uint8, s uint32) uint8 {
+	dd := uint32(d)
+	return uint8(dd + s - (dd*s)/255)
+}
+
+func max8(d uint8, s uint32) uint8 {
+	if s > uint32(d) {
+		return uint8(s)
+	}
+	return d
+}
+
+// Gradient is a color lookup table, for example for coloring particles over their life.
+type Gradient [256]color.RGBA
+
+// NewGradient returns a gradient going evenly through the supplied colors.
+// The colors are stored premultiplied.
+func NewGradient(colors ...color.Color) *Gradient {
+	var g Gradient
+	if len(colors) == 0 {
+		return &g
+	}
+	stops := make([]color.RGBA, len(colors))
+	for i, c := range colors {
+		stops[i] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+	if len(stops) == 1 {
+		for i := range g {
+			g[i] = stops[0]
+		}
+		return &g
+	}
+	for i := range g {
+		pos := float64(i) * float64(len(stops)-1) / 255
+		idx := int(pos)
+		if idx >= len(stops)-1 {
+			idx = len(stops) - 2
+		}
+		f := pos - float64(idx)
+		a, b := stops[idx], stops[idx+1]
+		lerp := func(a, b uint8) uint8 {
+			return uint8(math.Floor(float64(a) + (float64(b)-float64(a))*f + 0.5))
+		}
+		g[i] = color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: lerp(a.A, b.A)}
+	}
+	return &g
+}
+
+// At returns the color at position f, 0 -> 1.
+func (g *Gradient) At(f float32) color.RGBA {
+	if f <= 0 {
+		return g[0]
+	}
+	if f >= 1 {
+		return g[255]
+	}
+	return g[int(f*255+0.5)]
+}
+
+// Fire is a gradient going from white through yellow and red to transparent.
+// It can be used with Particle.Fade to color fire over the life of a particle.
+var Fire = NewGradient(
+	color.RGBA{R: 255, G: 255, B: 220, A: 255},
+	color.RGBA{R: 255, G: 200, B: 40, A: 255},
+	color.RGBA{R: 170, G: 45, B: 0, A: 200},
+	color.RGBA{R: 60, G: 10, B: 0, A: 100},
+	color.RGBA{},
+)
@@ -0,0 +1,162 @@
+package sprite
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// blendRGBA is a float reference of blendLineRGBA for one pixel.
+func blendRGBA(d [4]uint8, cov uint8, op Op, c color.RGBA) [4]uint8 {
+	k := float64(cov) / 255
+	src := [4]float64{float64(c.R) * k, float64(c.G) * k, float64(c.B) * k, float64(c.A) * k}
+	a := src[3] / 255
+	var out [4]uint8
+	for i := range d {
+		dv := float64(d[i])
+		var v float64
+		switch op {
+		case Add:
+			v = dv + src[i]
+		case Subtract:
+			v = dv - src[i]
+			if i == 3 {
+				v = dv
+			}
+		case Over:
+			v = src[i] + dv*(1-a)
+		case Multiply:
+			v = dv * (255 - float64(c.A)*k + src[i]) / 255
+			if i == 3 {
+				v = dv
+			}
+		case Screen:
+			v = dv + src[i] - dv*src[i]/255
+		case Max:
+			v = math.Max(dv, src[i])
+		}
+		out[i] = uint8(math.Max(0, math.Min(255, math.Floor(v+0.5))))
+	}
+	return out
+}
+
+func TestBlendLineRGBA(t *testing.T) {
+	colors := []color.RGBA{
+		{R: 255, G: 255, B: 255, A: 255},
+		{R: 255, G: 128, B: 0, A: 255},
+		// Premultiplied half transparent red.
+		{R: 128, A: 128},
+		// Fully transparent.
+		{},
+	}
+	dsts := [][4]uint8{{0, 0, 0, 0}, {0, 0, 200, 255}, {100, 150, 250, 255}, {60, 60, 60, 128}}
+	for op := Add; op <= Max; op++ {
+		for _, c := range colors {
+			for _, d := range dsts {
+				for _, cov := range []uint8{0, 1, 64, 128, 200, 255} {
+					dst := []byte{d[0], d[1], d[2], d[3]}
+					blendLineRGBA(dst, []byte{cov}, op, c)
+					want := blendRGBA(d, cov, op, c)
+					for i := range want {
+						diff := int(dst[i]) - int(want[i])
+						if diff < -1 || diff > 1 {
+							t.Errorf("op %d, color %v, coverage %d onto %v: got %v, want %v", op, c, cov, d, dst, want)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBlendLineRGBAEdges(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	tests := []struct {
+		name string
+		op   Op
+		c    color.RGBA
+		dst  [4]uint8
+		cov  uint8
+		want [4]uint8
+	}{
+		{"add clamps", Add, white, [4]uint8{200, 10, 255, 255}, 255, [4]uint8{255, 255, 255, 255}},
+		{"subtract clamps", Subtract, white, [4]uint8{200, 10, 0, 255}, 255, [4]uint8{0, 0, 0, 255}},
+		{"over opaque replaces", Over, color.RGBA{R: 10, G: 20, B: 30, A: 255}, [4]uint8{200, 200, 200, 255}, 255, [4]uint8{10, 20, 30, 255}},
+		{"over no coverage", Over, white, [4]uint8{1, 2, 3, 4}, 0, [4]uint8{1, 2, 3, 4}},
+		{"over transparent", Over, color.RGBA{}, [4]uint8{1, 2, 3, 4}, 255, [4]uint8{1, 2, 3, 4}},
+		{"over light on empty", Over, color.RGBA{R: 100, A: 100}, [4]uint8{}, 255, [4]uint8{100, 0, 0, 100}},
+		{"multiply white keeps", Multiply, white, [4]uint8{10, 100, 250, 255}, 255, [4]uint8{10, 100, 250, 255}},
+		{"multiply black", Multiply, color.RGBA{A: 255}, [4]uint8{10, 100, 250, 255}, 255, [4]uint8{0, 0, 0, 255}},
+		{"screen white", Screen, white, [4]uint8{10, 100, 250, 0}, 255, [4]uint8{255, 255, 255, 255}},
+		{"max", Max, color.RGBA{R: 50, G: 150, B: 0, A: 150}, [4]uint8{100, 100, 100, 100}, 255, [4]uint8{100, 150, 100, 150}},
+	}
+	for _, test := range tests {
+		dst := test.dst[:]
+		blendLineRGBA(dst, []byte{test.cov}, test.op, test.c)
+		if [4]uint8{dst[0], dst[1], dst[2], dst[3]} != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, dst, test.want)
+		}
+	}
+}
+
+func TestDrawRGBA(t *testing.T) {
+	s := solid(t)
+	dst := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	c := color.RGBA{R: 200, G: 100, B: 50, A: 255}
+	s.DrawRGBA(dst, 8*256, 8*256, 4*256, Nice, Over, c)
+	if got := dst.RGBAAt(8, 8); got != c {
+		t.Errorf("center: got %v, want %v", got, c)
+	}
+	if got := dst.RGBAAt(0, 0); got != (color.RGBA{}) {
+		t.Errorf("outside: got %v, want nothing", got)
+	}
+	// Sprites partly outside the image are clipped.
+	s.DrawRotatedRGBA(dst, 0, 15*256, 6*256, 1, Nice, Add, c)
+	if got := dst.RGBAAt(0, 15); got.R < c.R {
+		t.Errorf("corner: got %v, want at least %v", got, c)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	g := NewGradient(color.RGBA{A: 255}, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	if g.At(-1) != g[0] || g.At(0) != (color.RGBA{A: 255}) {
+		t.Errorf("start: got %v", g.At(0))
+	}
+	if g.At(2) != g[255] || g.At(1) != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("end: got %v", g.At(1))
+	}
+	if got := g.At(0.5); got.R < 126 || got.R > 129 {
+		t.Errorf("middle: got %v", got)
+	}
+	// Colors are premultiplied, so no channel is above alpha.
+	for i, c := range Fire {
+		if c.R > c.A || c.G > c.A || c.B > c.A {
+			t.Fatalf("Fire entry %d is not premultiplied: %v", i, c)
+		}
+	}
+	if one := NewGradient(color.White); one[0] != one[255] {
+		t.Error("a single color should fill the gradient")
+	}
+}
+
+func TestDrawBlurredRGBA(t *testing.T) {
+	s := solid(t)
+	c := color.RGBA{R: 200, G: 100, B: 50, A: 255}
+	sum := func(blur int32) (total int) {
+		dst := image.NewRGBA(image.Rect(0, 0, 64, 64))
+		s.DrawBlurredRGBA(dst, 32*256, 32*256, 8*256, blur, 0, Nice, Add, c)
+		for i := 0; i < len(dst.Pix); i += 4 {
+			total += int(dst.Pix[i])
+		}
+		return total
+	}
+	// Blurring spreads the color, but keeps the total about the same.
+	sharp := sum(0)
+	for _, blur := range []int32{256, 4 * 256, 12 * 256} {
+		if got := sum(blur); math.Abs(float64(got-sharp)) > float64(sharp)*0.15 {
+			t.Errorf("blur %d: total %d, sharp total %d", blur, got, sharp)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package sprite
+
+import (
+	"image"
+
+	"github.com/klauspost/gad/fixed"
+)
+
+// rotMapping contains the information needed to draw a rotated sprite.
+// Texture coordinates are stepped like a rotozoomer over the bounding box
+// of the rotated sprite. Pixels outside the texture are not drawn.
+type rotMapping struct {
+	// Bounding box in screen space, clipped to the screen.
+	startX, endX, startY, endY int32
+
+	// Texture coordinate of the first pixel as 16.16 fixed point.
+	u0, v0 int32
+
+	// Texture steps for one pixel right (dx) and one line down (dy).
+	uDx, vDx, uDy, vDy int32
+
+	// The size (width/height) of the chosen mip in uv scale.
+	mipSize int32
+
+	// The image to draw from.
+	// If nil, do not draw anything.
+	mip *image.Gray
+}
+
+// calcRotated returns a mapping for a sprite with radius r placed at (x,y),
+// rotated angle radians clockwise.
+// The mapping is clipped to a screen of width * height pixels.
+func (s *Sprite) calcRotated(width, height, x, y, r int32, angle float32, mip int32) rotMapping {
+	var m rotMapping
+	// Sine and cosine from the tables as 16.16, since this is done for every sprite.
+	a := fixed.Radians(float64(angle))
+	sin, cos := int64(fixed.Sin(a)), int64(fixed.Cos(a))
+
+	// Half size of the bounding box.
+	e := int32(int64(r) * (abs64(sin) + abs64(cos)) >> 16)
+
+	// Quick discard
+	if x+e < 0 || x-e > (width*256) || y+e < 0 || y-e > (height*256) {
+		return m
+	}
+	if mip < 0 {
+		mip = s.mipLevel(r)
+	}
+	m.mip = s.Mips[mip]
+	m.mipSize = int32(1<<16) << uint(mip)
+
+	// Texture pixels per screen pixel as 16.16.
+	scale := int64(65536 * float64(m.mip.Rect.Dx()) / (float64(r) / 128))
+	m.uDx, m.vDx = int32(cos*scale>>16), int32(-sin*scale>>16)
+	m.uDy, m.vDy = int32(sin*scale>>16), int32(cos*scale>>16)
+
+	// Screen space, clipped.
+	m.startX, m.startY = (x-e)>>8, (y-e)>>8
+	m.endX, m.endY = (x+e+255)>>8, (y+e+255)>>8
+	if m.startX < 0 {
+		m.startX = 0
+	}
+	if m.startY < 0 {
+		m.startY = 0
+	}
+	if m.endX > width {
+		m.endX = width
+	}
+	if m.endY > height {
+		m.endY = height
+	}
+
+	// Distance from the sprite center to the center of the first pixel.
+	// Both are 24.8 and the steps are 16.16, so the result is shifted by 8.
+	dx := int64(m.startX)<<8 + 128 - int64(x)
+	dy := int64(m.startY)<<8 + 128 - int64(y)
+	m.u0 = m.mipSize/2 + int32((dx*int64(m.uDx)+dy*int64(m.uDy))>>8)
+	m.v0 = m.mipSize/2 + int32((dx*int64(m.vDx)+dy*int64(m.vDy))>>8)
+	return m
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// sampleRotated will sample lines of a rotated sprite.
+// If bilinear is false nearest neighbor sampling is used.
+func (s *Sprite) sampleRotated(m *rotMapping, bilinear bool, fn lineFn) {
+	row := s.rowBuffer(m.endX - m.startX)
+	size := uint32(m.mipSize)
+	stride := uint32(m.mip.Stride)
+	pix := m.mip.Pix
+	u0, v0 := m.u0, m.v0
+	for y := m.startY; y < m.endY; y++ {
+		u, v := u0, v0
+		for x := range row {
+			// Negative values will wrap and be outside.
+			uu, vv := uint32(u), uint32(v)
+			u += m.uDx
+			v += m.vDx
+			if uu >= size || vv >= size {
+				row[x] = 0
+				continue
+			}
+			line0 := pix[(vv>>16)*stride:]
+			if !bilinear {
+				row[x] = line0[uu>>16]
+				continue
+			}
+			line1 := line0
+			if vv+65536 < size {
+				line1 = line0[stride:]
+			}
+			xPos0 := uu >> 16
+			xPos1 := xPos0
+			if uu+65536 < size {
+				xPos1++
+			}
+			uf1, vf1 := (uu&0xffff)>>8, (vv&0xffff)>>8
+			uf0, vf0 := 256-uf1, 256-vf1
+			p := uint32(line0[xPos0]) * uf0 * vf0
+			p += uint32(line0[xPos1]) * uf1 * vf0
+			p += uint32(line1[xPos0]) * uf0 * vf1
+			p += uint32(line1[xPos1]) * uf1 * vf1
+			row[x] = uint8(p >> 16)
+		}
+		fn(m.startX, y, row)
+		u0 += m.uDy
+		v0 += m.vDy
+	}
+}
@@ -0,0 +1,196 @@
+// Package sprite draws scaled sprites with subpixel precision.
+//
+// Positions and radius are given as 24.8 fixed point,
+// so 256 equals one pixel on screen.
+package sprite
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math/bits"
+
+	"github.com/klauspost/gad/mipmap"
+	"github.com/klauspost/gfx"
+	"golang.org/x/image/draw"
+)
+
+// Mode selects how a sprite is drawn.
+type Mode int
+
+const (
+	// Fast draws with nearest neighbor scaling from the full size image.
+	Fast Mode = iota
+	// Mip draws with nearest neighbor scaling from the closest mipmap.
+	Mip
+	// Nice draws with bilinear interpolation from the closest mipmap.
+	Nice
+	// Go draws with golang.org/x/image/draw for reference.
+	Go
+)
+
+// Sprite is a square image and its mipmaps.
+// A sprite can only be drawn by one goroutine at the time.
+type Sprite struct {
+	// Mips contains the image and mipmaps.
+	// The size of a mipmap in pixels is 1 << level,
+	// so the last entry is the full size image.
+	Mips []*image.Gray
+
+	// row is a buffer for a line of sampled pixels.
+	row []byte
+}
+
+// New creates a sprite from a square image with power of two size.
+// Mipmaps are created with a box filter.
+// The image is used directly and should not be modified.
+func New(img *image.Gray) (*Sprite, error) {
+	return NewFiltered(img, mipmap.Box)
+}
+
+// NewFiltered creates a sprite like New, but creates mipmaps with the filter f.
+func NewFiltered(img *image.Gray, f mipmap.Filter) (*Sprite, error) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	logW := uint(bits.Len32(uint32(w))) - 1
+	if w != 1<<logW || h != 1<<logW {
+		return nil, errors.New("sprite: image size must be a square power of two")
+	}
+
+	// The mipmap chain starts with the full size image, we want it last.
+	chain := mipmap.Gray(img, f)
+	var s Sprite
+	s.Mips = make([]*image.Gray, len(chain))
+	for i, mip := range chain {
+		s.Mips[len(chain)-1-i] = mip
+	}
+	return &s, nil
+}
+
+// Load will load a sprite from a picture added to gfx.
+// The picture is converted to grayscale.
+func Load(file string) (*Sprite, error) {
+	img, err := gfx.LoadPalPicture(file)
+	if err != nil {
+		return nil, err
+	}
+	grey := image.NewGray(img.Rect)
+	draw.Draw(grey, grey.Rect, img, image.Pt(0, 0), draw.Src)
+	return New(grey)
+}
+
+// Draw the sprite centered at x,y with radius r onto dst.
+// The sprite is added to the destination.
+// Input is assumed to be 24.8
+func (s *Sprite) Draw(dst *image.Gray, x, y, r int32, mode Mode) {
+	s.DrawBlend(dst, x, y, r, mode, Additive)
+}
+
+// DrawBlend draws the sprite centered at x,y with radius r onto dst
+// and combines it with the destination as specified by b.
+// Input is assumed to be 24.8
+func (s *Sprite) DrawBlend(dst *image.Gray, x, y, r int32, mode Mode, b Blend) {
+	if mode == Go {
+		s.drawGo(dst, x, y, r)
+		return
+	}
+	s.DrawRotated(dst, x, y, r, 0, mode, b)
+}
+
+// DrawRotated draws the sprite centered at x,y with radius r, rotated angle radians clockwise.
+// The sprite is combined with the destination as specified by b.
+// The Go mode is not supported and will draw as Nice.
+// Input is assumed to be 24.8
+func (s *Sprite) DrawRotated(dst *image.Gray, x, y, r int32, angle float32, mode Mode, b Blend) {
+	if mode == Go {
+		mode = Nice
+	}
+	s.render(int32(dst.Rect.Dx()), int32(dst.Rect.Dy()), x, y, r, angle, mode, -1, func(x, y int32, row []byte) {
+		b.blendLine(dst.Pix[int(y)*dst.Stride+int(x):][:len(row)], row)
+	})
+}
+
+// DrawBlurred draws the sprite like DrawRotated, but out of focus.
+// blur is added to the radius and the sprite is drawn from a smaller mipmap,
+// which is magnified with bilinear filtering to give the blur.
+// The mode is only used when blur is 0.
+// The opacity is lowered so the total brightness matches the sharp sprite.
+// Input is assumed to be 24.8
+func (s *Sprite) DrawBlurred(dst *image.Gray, x, y, r, blur int32, angle float32, mode Mode, b Blend) {
+	if blur <= 0 {
+		s.DrawRotated(dst, x, y, r, angle, mode, b)
+		return
+	}
+	mode = Nice
+	if r <= 0 {
+		return
+	}
+	br, fade, mip := s.blurred(r, blur)
+	b.Opacity = uint8((int64(b.Opacity)*fade + 128) >> 8)
+	s.render(int32(dst.Rect.Dx()), int32(dst.Rect.Dy()), x, y, br, angle, mode, mip, func(x, y int32, row []byte) {
+		b.blendLine(dst.Pix[int(y)*dst.Stride+int(x):][:len(row)], row)
+	})
+}
+
+// blurred returns the radius, the brightness as 0 -> 256 and the mipmap
+// to draw a sprite with radius r and blur added.
+func (s *Sprite) blurred(r, blur int32) (br int32, fade int64, mip int32) {
+	// Brightness is spread over a bigger area.
+	br = r + blur
+	fade = (int64(r) * int64(r) << 8) / (int64(br) * int64(br))
+
+	// Select the mipmap as if the sprite was drawn at the sharp size
+	// with the same amount of detail spread over the blurred size.
+	mip = s.mipLevel(int32((int64(r) * int64(r)) / int64(br)))
+	return br, fade, mip
+}
+
+// DrawRGBA draws the sprite centered at x,y with radius r onto dst.
+// The sprite is used as coverage of the color c, which must be premultiplied.
+// The result is combined with the destination using op.
+// The Go mode is not supported and will draw as Nice.
+// Input is assumed to be 24.8
+func (s *Sprite) DrawRGBA(dst *image.RGBA, x, y, r int32, mode Mode, op Op, c color.RGBA) {
+	s.DrawRotatedRGBA(dst, x, y, r, 0, mode, op, c)
+}
+
+// DrawRotatedRGBA draws the sprite onto dst like DrawRGBA, rotated angle radians clockwise.
+// Input is assumed to be 24.8
+func (s *Sprite) DrawRotatedRGBA(dst *image.RGBA, x, y, r int32, angle float32, mode Mode, op Op, c color.RGBA) {
+	if mode == Go {
+		mode = Nice
+	}
+	s.render(int32(dst.Rect.Dx()), int32(dst.Rect.Dy()), x, y, r, angle, mode, -1, func(x, y int32, row []byte) {
+		blendLineRGBA(dst.Pix[int(y)*dst.Stride+int(x)*4:][:len(row)*4], row, op, c)
+	})
+}
+
+// DrawBlurredRGBA draws the sprite onto dst like DrawBlurred,
+// using the color c like DrawRGBA.
+// Input is assumed to be 24.8
+func (s *Sprite) DrawBlurredRGBA(dst *image.RGBA, x, y, r, blur int32, angle float32, mode Mode, op Op, c color.RGBA) {
+	if blur <= 0 {
+		s.DrawRotatedRGBA(dst, x, y, r, angle, mode, op, c)
+		return
+	}
+	if r <= 0 {
+		return
+	}
+	br, fade, mip := s.blurred(r, blur)
+	// The color is premultiplied, so all channels are faded.
+	c = color.RGBA{
+		R: uint8((int64(c.R)*fade + 128) >> 8),
+		G: uint8((int64(c.G)*fade + 128) >> 8),
+		B: uint8((int64(c.B)*fade + 128) >> 8),
+		A: uint8((int64(c.A)*fade + 128) >> 8),
+	}
+	s.render(int32(dst.Rect.Dx()), int32(dst.Rect.Dy()), x, y, br, angle, Nice, mip, func(x, y int32, row []byte) {
+		blendLineRGBA(dst.Pix[int(y)*dst.Stride+int(x)*4:][:len(row)*4], row, op, c)
+	})
+}
+
+// DrawFn returns a function that draws the sprite onto dst with the specified mode.
+func (s *Sprite) DrawFn(dst *image.Gray, mode Mode) func(x, y, r int32) {
+	return func(x, y, r int32) {
+		s.Draw(dst, x, y, r, mode)
+	}
+}
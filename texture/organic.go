@@ -0,0 +1,79 @@
+package texture
+
+import (
+	"math"
+
+	"github.com/klauspost/gad/noise"
+	"github.com/klauspost/gad/rng"
+)
+
+// Patterns based on noise. They are seeded, so the same seed
+// always gives the same texture.
+
+// Clouds returns fBm noise with cells * cells noise cells.
+func Clouds(seed int64, cells int) Pattern {
+	n := noise.New(seed)
+	return func(x, y, w, h int) uint8 {
+		u, v := float64(x*cells)/float64(w), float64(y*cells)/float64(h)
+		return clamp8((n.FBM2Tile(u, v, cells, cells, noise.DefaultFractal) + 1) * 127.5)
+	}
+}
+
+// Marble returns veins of marble going across the texture.
+// Turbulence is how much the veins are disturbed, around 1 to 5.
+func Marble(seed int64, veins int, turbulence float64) Pattern {
+	n := noise.New(seed)
+	const cells = 4
+	return func(x, y, w, h int) uint8 {
+		u, v := float64(x)/float64(w), float64(y)/float64(h)
+		t := n.FBM2Tile(u*cells, v*cells, cells, cells, noise.DefaultFractal)
+		f := math.Sin((u+v)*float64(veins)*math.Pi*2 + t*turbulence)
+		// Sharpen the veins.
+		f = 1 - math.Sqrt(math.Abs(f))
+		return clamp8(f * 255)
+	}
+}
+
+// Wood returns rings of wood around the center of the texture.
+func Wood(seed int64, rings int) Pattern {
+	n := noise.New(seed)
+	const cells = 8
+	return func(x, y, w, h int) uint8 {
+		u, v := float64(x)/float64(w), float64(y)/float64(h)
+		t := n.Perlin2Tile(u*cells, v*cells*0.25, cells, cells/4)
+		f := (wrapDist(u, v) + t*0.08) * float64(rings)
+		f -= math.Floor(f)
+		// Rings have a soft start and a hard end.
+		return clamp8(f * f * 255)
+	}
+}
+
+// Truchet returns tiles with quarter circles in random orientation,
+// which connect into winding lines.
+// There are cells * cells tiles and cells should divide the size.
+func Truchet(seed int64, cells int) Pattern {
+	r := rng.New(seed)
+	flip := make([]bool, cells*cells)
+	for i := range flip {
+		flip[i] = r.Intn(2) == 0
+	}
+	return func(x, y, w, h int) uint8 {
+		cx, cy := x*cells/w, y*cells/h
+		// Position inside the tile, 0 -> 1.
+		tx := float64(x*cells-cx*w) / float64(w)
+		ty := float64(y*cells-cy*h) / float64(h)
+		if flip[cy*cells+cx] {
+			tx = 1 - tx
+		}
+		// Distance to the closest arc, centered on two opposite corners.
+		d0 := math.Abs(math.Hypot(tx, ty) - 0.5)
+		d1 := math.Abs(math.Hypot(1-tx, 1-ty) - 0.5)
+		d := math.Min(d0, d1)
+		const width = 0.15
+		if d >= width {
+			return 0
+		}
+		// Shade the lines, so they look round.
+		return clamp8(math.Cos(d/width*math.Pi/2) * 255)
+	}
+}
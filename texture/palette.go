@@ -0,0 +1,86 @@
+package texture
+
+import (
+	"image/color"
+	"math"
+)
+
+// NewPalette returns a 256 color palette going evenly through the colors.
+// If cycle is set, it returns to the first color at the end,
+// so it can be rotated without a seam.
+func NewPalette(cycle bool, colors ...color.Color) color.Palette {
+	pal := make(color.Palette, 256)
+	if len(colors) == 0 {
+		for i := range pal {
+			pal[i] = color.RGBA{A: 255}
+		}
+		return pal
+	}
+	stops := make([]color.RGBA, 0, len(colors)+1)
+	for _, c := range colors {
+		stops = append(stops, color.RGBAModel.Convert(c).(color.RGBA))
+	}
+	if cycle {
+		stops = append(stops, stops[0])
+	}
+	if len(stops) == 1 {
+		for i := range pal {
+			pal[i] = stops[0]
+		}
+		return pal
+	}
+	// When cycling the last stop is the first entry again, so it isn't included.
+	last := 255.0
+	if cycle {
+		last = 256
+	}
+	for i := range pal {
+		pos := float64(i) * float64(len(stops)-1) / last
+		idx := int(pos)
+		if idx >= len(stops)-1 {
+			idx = len(stops) - 2
+		}
+		f := pos - float64(idx)
+		a, b := stops[idx], stops[idx+1]
+		lerp := func(a, b uint8) uint8 {
+			return uint8(math.Floor(float64(a) + (float64(b)-float64(a))*f + 0.5))
+		}
+		pal[i] = color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: lerp(a.A, b.A)}
+	}
+	return pal
+}
+
+// Predefined palettes.
+var (
+	GreyPalette = NewPalette(false, color.Black, color.White)
+
+	// RainbowPalette cycles through all hues.
+	RainbowPalette = NewPalette(true,
+		color.RGBA{R: 255, A: 255},
+		color.RGBA{R: 255, G: 255, A: 255},
+		color.RGBA{G: 255, A: 255},
+		color.RGBA{G: 255, B: 255, A: 255},
+		color.RGBA{B: 255, A: 255},
+		color.RGBA{R: 255, B: 255, A: 255},
+	)
+
+	FirePalette = NewPalette(false,
+		color.RGBA{A: 255},
+		color.RGBA{R: 120, G: 10, A: 255},
+		color.RGBA{R: 230, G: 80, A: 255},
+		color.RGBA{R: 255, G: 200, B: 40, A: 255},
+		color.RGBA{R: 255, G: 255, B: 220, A: 255},
+	)
+
+	MarblePalette = NewPalette(false,
+		color.RGBA{R: 230, G: 225, B: 215, A: 255},
+		color.RGBA{R: 170, G: 165, B: 160, A: 255},
+		color.RGBA{R: 40, G: 45, B: 55, A: 255},
+	)
+
+	WoodPalette = NewPalette(false,
+		color.RGBA{R: 200, G: 140, B: 80, A: 255},
+		color.RGBA{R: 160, G: 100, B: 50, A: 255},
+		color.RGBA{R: 90, G: 50, B: 20, A: 255},
+	)
+)
@@ -0,0 +1,69 @@
+package texture
+
+import "math"
+
+// XOR is the classic x^y pattern.
+// Coordinates are scaled to 0 -> 255, so it covers all values at any size.
+func XOR(x, y, w, h int) uint8 {
+	return uint8(x*256/w) ^ uint8(y*256/h)
+}
+
+// AND is the x&y pattern, a Sierpinski triangle.
+func AND(x, y, w, h int) uint8 {
+	return uint8(x*256/w) & uint8(y*256/h)
+}
+
+// Checker returns a checkerboard with cells * cells squares.
+// Cells should divide the size for the texture to tile.
+func Checker(cells int) Pattern {
+	return func(x, y, w, h int) uint8 {
+		if (x*cells/w+y*cells/h)&1 == 0 {
+			return 255
+		}
+		return 0
+	}
+}
+
+// Gradient returns a linear gradient going from 0 to 255 repeats times
+// across the texture, rotated by angle radians.
+// It only tiles for angles that are multiples of 90 degrees.
+func Gradient(angle float64, repeats int) Pattern {
+	s, c := math.Sincos(angle)
+	return func(x, y, w, h int) uint8 {
+		u, v := float64(x)/float64(w), float64(y)/float64(h)
+		f := (u*c + v*s) * float64(repeats)
+		f -= math.Floor(f)
+		return clamp8(f * 256)
+	}
+}
+
+// Radial returns a gradient which is 255 at the center
+// of the texture and 0 at the edges.
+// Distances wrap around the edges, so it tiles.
+func Radial(x, y, w, h int) uint8 {
+	d := wrapDist(float64(x)/float64(w), float64(y)/float64(h))
+	return clamp8(255 - d*255)
+}
+
+// wrapDist returns the distance from (u, v) to the center of a tile
+// measured on a torus, so the result tiles. It is 0 at the center
+// and 1 at the corners.
+func wrapDist(u, v float64) float64 {
+	su, sv := math.Sin(math.Pi*u), math.Sin(math.Pi*v)
+	return math.Sqrt((2 - su*su - sv*sv) * 0.5)
+}
+
+// Plasma returns a plasma of added sines.
+// Frequencies are whole numbers, so it tiles.
+// Higher detail adds more and faster sines.
+func Plasma(detail int) Pattern {
+	return func(x, y, w, h int) uint8 {
+		u, v := float64(x)/float64(w)*math.Pi*2, float64(y)/float64(h)*math.Pi*2
+		var sum float64
+		for i := 1; i <= detail; i++ {
+			f := float64(i)
+			sum += math.Sin(u*f+f) + math.Sin(v*f+2*f) + math.Sin((u+v)*f) + math.Sin((u-v)*f+3*f)
+		}
+		return clamp8((sum/float64(detail*4) + 1) * 127.5)
+	}
+}
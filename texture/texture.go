@@ -0,0 +1,82 @@
+// Package texture generates textures in code,
+// so effects can run without any external assets.
+//
+// A Pattern returns a value for each pixel, which is stored directly
+// in an *image.Gray or used as an index into a generated palette
+// in an *image.Paletted.
+// Texture sizes must be powers of two and all patterns tile seamlessly
+// unless noted, so textures can be wrapped with a mask.
+package texture
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// Pattern returns the value of pixel (x, y) in a w * h texture.
+type Pattern func(x, y, w, h int) uint8
+
+// Gray returns a w * h texture of the pattern.
+func Gray(w, h int, p Pattern) (*image.Gray, error) {
+	if err := checkSize(w, h); err != nil {
+		return nil, err
+	}
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	fill(img.Pix, img.Stride, w, h, p)
+	return img, nil
+}
+
+// Paletted returns a w * h texture of the pattern,
+// using the pattern values as palette indexes.
+func Paletted(w, h int, p Pattern, pal color.Palette) (*image.Paletted, error) {
+	if err := checkSize(w, h); err != nil {
+		return nil, err
+	}
+	if len(pal) == 0 {
+		return nil, errors.New("texture: empty palette")
+	}
+	img := image.NewPaletted(image.Rect(0, 0, w, h), pal)
+	fill(img.Pix, img.Stride, w, h, p)
+	if len(pal) < 256 {
+		// Keep indexes inside the palette.
+		for i, v := range img.Pix {
+			img.Pix[i] = uint8(int(v) * len(pal) / 256)
+		}
+	}
+	return img, nil
+}
+
+func fill(pix []byte, stride, w, h int, p Pattern) {
+	for y := 0; y < h; y++ {
+		line := pix[y*stride : y*stride+w]
+		for x := range line {
+			line[x] = p(x, y, w, h)
+		}
+	}
+}
+
+func checkSize(w, h int) error {
+	if w <= 0 || h <= 0 || w&(w-1) != 0 || h&(h-1) != 0 {
+		return errors.New("texture: size must be a power of two")
+	}
+	return nil
+}
+
+// clamp8 returns v clamped to 0 -> 255.
+func clamp8(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// Invert returns a pattern with the values of p reversed.
+func Invert(p Pattern) Pattern {
+	return func(x, y, w, h int) uint8 {
+		return 255 - p(x, y, w, h)
+	}
+}
@@ -0,0 +1,67 @@
+// Package tick turns effect time into fixed simulation steps.
+//
+// Effects get t, which runs from 0 to 1 and starts over when the effect loops.
+// Simulations like fire and water need steps of a fixed length instead,
+// so they look the same at any frame rate.
+// The length of a loop is not known to the effect, so it must be given.
+package tick
+
+// MaxSteps is the default maximum number of steps made for a single frame,
+// so a slow frame doesn't make the next frame even slower.
+const MaxSteps = 8
+
+// Clock counts the steps to make for each frame.
+type Clock struct {
+	// Rate is the number of steps per second.
+	Rate float64
+
+	// Duration is the length of one loop of t in seconds.
+	// It must match the duration the effect is run with.
+	// If Rate or Duration is 0, one step is made for each frame.
+	Duration float64
+
+	// Max is the maximum number of steps for a single frame. 0 gives MaxSteps.
+	Max int
+
+	last    float64
+	pending float64
+}
+
+// Advance returns the number of steps to make for the time
+// from the previous call to t.
+// If t is before the previous time the effect has looped, so the
+// time to the end of the loop and from the start to t is counted
+// and looped is true.
+func (c *Clock) Advance(t float64) (n int, looped bool) {
+	dt := t - c.last
+	if dt < 0 {
+		dt += 1
+		looped = true
+	}
+	c.last = t
+	if c.Rate <= 0 || c.Duration <= 0 {
+		return 1, looped
+	}
+	c.pending += dt * c.Duration * c.Rate
+	n = int(c.pending)
+	c.pending -= float64(n)
+	max := c.Max
+	if max <= 0 {
+		max = MaxSteps
+	}
+	if n > max {
+		n = max
+	}
+	return n, looped
+}
+
+// Last returns the time of the previous call to Advance or Reset.
+func (c *Clock) Last() float64 {
+	return c.last
+}
+
+// Reset starts counting from time t with no steps pending.
+func (c *Clock) Reset(t float64) {
+	c.last = t
+	c.pending = 0
+}
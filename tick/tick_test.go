@@ -0,0 +1,51 @@
+package tick
+
+import "testing"
+
+func TestAdvance(t *testing.T) {
+	c := Clock{Rate: 60, Duration: 10}
+	total := 0
+	for i := 1; i <= 600; i++ {
+		n, looped := c.Advance(float64(i) / 600)
+		if looped {
+			t.Fatalf("frame %d: unexpected loop", i)
+		}
+		total += n
+	}
+	if total != 600 {
+		t.Errorf("got %d steps for one loop, want 600", total)
+	}
+}
+
+func TestAdvanceLoop(t *testing.T) {
+	c := Clock{Rate: 8, Duration: 1}
+	c.Reset(0.875)
+	n, looped := c.Advance(0.125)
+	if !looped {
+		t.Error("going backwards should loop")
+	}
+	if n != 2 {
+		t.Errorf("got %d steps over the loop, want 2", n)
+	}
+}
+
+func TestAdvanceMax(t *testing.T) {
+	c := Clock{Rate: 1000, Duration: 1}
+	if n, _ := c.Advance(0.5); n != MaxSteps {
+		t.Errorf("got %d steps, want %d", n, MaxSteps)
+	}
+	c = Clock{Rate: 1000, Duration: 1, Max: 3}
+	if n, _ := c.Advance(0.5); n != 3 {
+		t.Errorf("got %d steps, want 3", n)
+	}
+}
+
+func TestAdvanceFrames(t *testing.T) {
+	// Without a duration, every frame is one step.
+	c := Clock{Rate: 60}
+	for _, v := range []float64{0, 0, 0.5, 0.2} {
+		if n, _ := c.Advance(v); n != 1 {
+			t.Errorf("t=%v: got %d steps, want 1", v, n)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/klauspost/gad/voxel"
+	"github.com/klauspost/gfx"
+)
+
+const (
+	renderWidth  = 640
+	renderHeight = 360
+	mapSize      = 1024
+)
+
+func main() {
+	m, err := voxel.Generate(1, mapSize)
+	if err != nil {
+		panic(err)
+	}
+	fx, err := voxel.New(renderWidth, renderHeight, m, voxel.Config{
+		Camera:      flight(m),
+		Distance:    800,
+		HeightScale: 1.5,
+		Fog:         color.RGBA{R: 180, G: 200, B: 220, A: 255},
+		FogStart:    0.3,
+		Sky:         color.RGBA{R: 40, G: 90, B: 180, A: 255},
+	})
+	if err != nil {
+		panic(err)
+	}
+	gfx.Run(func() { gfx.RunTimed(fx) })
+}
+
+// flight returns a camera flying a loop around the map,
+// keeping above the ground and looking where it goes.
+func flight(m *voxel.Map) func(t float64) voxel.Camera {
+	return func(t float64) voxel.Camera {
+		a := t * 2 * math.Pi
+		x := mapSize/2 + math.Sin(a)*mapSize/3
+		y := mapSize/2 - math.Sin(2*a)*mapSize/4
+		dx, dy := math.Cos(a)*mapSize/3, -math.Cos(2*a)*mapSize/2
+		ground := math.Max(m.HeightAt(x, y), m.HeightAt(x+dx*0.02, y+dy*0.02))
+		return voxel.Camera{
+			X:       x,
+			Y:       y,
+			Height:  ground + 50,
+			Heading: math.Atan2(dx, -dy),
+			Pitch:   -0.15 + math.Sin(a*3)*0.05,
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package voxel
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/klauspost/gad/noise"
+	"github.com/klauspost/gad/texture"
+)
+
+// TerrainPalette goes from water through grass and rock to snow.
+var TerrainPalette = texture.NewPalette(false,
+	color.RGBA{R: 20, G: 50, B: 110, A: 255},
+	color.RGBA{R: 60, G: 110, B: 150, A: 255},
+	color.RGBA{R: 200, G: 190, B: 130, A: 255},
+	color.RGBA{R: 70, G: 130, B: 50, A: 255},
+	color.RGBA{R: 40, G: 90, B: 30, A: 255},
+	color.RGBA{R: 110, G: 100, B: 90, A: 255},
+	color.RGBA{R: 150, G: 145, B: 140, A: 255},
+	color.RGBA{R: 250, G: 250, B: 255, A: 255},
+)
+
+const (
+	// Generated color maps have this many bands of height,
+	// each with shadeLevels levels of light.
+	heightBands = 32
+	shadeLevels = 256 / heightBands
+	// Heights below this are water, which is flat.
+	waterLevel = 70
+)
+
+// Generate returns a size * size map generated from Perlin noise,
+// colored with the terrain palette.
+// The slopes are lit from the left.
+// Size must be a power of two.
+func Generate(seed int64, size int) (*Map, error) {
+	n := noise.New(seed)
+	height, err := n.PerlinTexture(size, size, 4, noise.Fractal{Octaves: 7, Lacunarity: 2, Gain: 0.5})
+	if err != nil {
+		return nil, err
+	}
+	// Flatten the water and stretch the rest to the full range.
+	for i, v := range height.Pix {
+		if v < waterLevel {
+			v = waterLevel
+		}
+		height.Pix[i] = uint8(int(v-waterLevel) * 255 / (255 - waterLevel))
+	}
+
+	// Each palette entry is a height band with a light level.
+	pal := make(color.Palette, 256)
+	for band := 0; band < heightBands; band++ {
+		c := color.RGBAModel.Convert(TerrainPalette[band*256/heightBands]).(color.RGBA)
+		for l := 0; l < shadeLevels; l++ {
+			f := 96 + l*160/(shadeLevels-1)
+			pal[band*shadeLevels+l] = color.RGBA{
+				R: clamp8(int(c.R) * f >> 8),
+				G: clamp8(int(c.G) * f >> 8),
+				B: clamp8(int(c.B) * f >> 8),
+				A: 255,
+			}
+		}
+	}
+	colors := image.NewPaletted(height.Rect, pal)
+	mask := size - 1
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			h := int(height.Pix[y*height.Stride+x])
+			// Light from the slope towards the left.
+			left := int(height.Pix[y*height.Stride+(x-1)&mask])
+			right := int(height.Pix[y*height.Stride+(x+1)&mask])
+			l := shadeLevels/2 + (left-right)/2
+			if l < 0 {
+				l = 0
+			}
+			if l >= shadeLevels {
+				l = shadeLevels - 1
+			}
+			colors.Pix[y*colors.Stride+x] = uint8(h*heightBands/256*shadeLevels + l)
+		}
+	}
+	return NewMap(height, colors)
+}
+
+func clamp8(v int) uint8 {
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
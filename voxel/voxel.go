@@ -0,0 +1,259 @@
+// Package voxel renders landscapes from a height map and a color map,
+// like the voxel space engine of Comanche.
+//
+// For each distance, going from the camera and outwards, a line across
+// the map is sampled and each column is drawn from the projected height
+// down to the highest pixel drawn so far, the y-buffer.
+// This way every pixel is only drawn once and far terrain hidden
+// behind hills is skipped.
+package voxel
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Map is a landscape. The height and color maps wrap around,
+// so the landscape is endless.
+type Map struct {
+	Height *image.Gray
+	Color  *image.Paletted
+
+	maskX      int
+	maskY      int
+	colors     []color.RGBA
+	heightPix  []byte
+	colorPix   []byte
+	heightStep int
+	colorStep  int
+}
+
+// NewMap returns a map with the given height and color maps.
+// Both must have the same size, which must be powers of two.
+func NewMap(height *image.Gray, colors *image.Paletted) (*Map, error) {
+	w, h := height.Rect.Dx(), height.Rect.Dy()
+	if w <= 0 || h <= 0 || w&(w-1) != 0 || h&(h-1) != 0 {
+		return nil, errors.New("voxel: map size must be a power of two")
+	}
+	if colors.Rect.Dx() != w || colors.Rect.Dy() != h {
+		return nil, errors.New("voxel: height and color map sizes differ")
+	}
+	m := Map{
+		Height:     height,
+		Color:      colors,
+		maskX:      w - 1,
+		maskY:      h - 1,
+		heightPix:  height.Pix,
+		colorPix:   colors.Pix,
+		heightStep: height.Stride,
+		colorStep:  colors.Stride,
+	}
+	m.colors = make([]color.RGBA, 256)
+	for i := range m.colors {
+		m.colors[i] = color.RGBA{A: 255}
+		if i < len(colors.Palette) {
+			m.colors[i] = color.RGBAModel.Convert(colors.Palette[i]).(color.RGBA)
+		}
+	}
+	return &m, nil
+}
+
+// HeightAt returns the height at map position (x, y), interpolated between pixels.
+// Use it to keep a camera above the ground.
+func (m *Map) HeightAt(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	ix, iy := int(fx), int(fy)
+	dx, dy := x-fx, y-fy
+	at := func(x, y int) float64 {
+		return float64(m.heightPix[(y&m.maskY)*m.heightStep+(x&m.maskX)])
+	}
+	top := at(ix, iy)*(1-dx) + at(ix+1, iy)*dx
+	bottom := at(ix, iy+1)*(1-dx) + at(ix+1, iy+1)*dx
+	return top*(1-dy) + bottom*dy
+}
+
+// Camera is the view of the landscape.
+type Camera struct {
+	// Position on the map.
+	X, Y float64
+	// Height above height 0.
+	Height float64
+	// Heading in radians. 0 looks towards negative Y, up on the map,
+	// and positive values turn right.
+	Heading float64
+	// Pitch in radians. Positive values look up, which moves the horizon down.
+	Pitch float64
+	// Horizon is the screen line of the horizon with no pitch,
+	// as a fraction of the screen height. 0 gives 0.5.
+	Horizon float64
+}
+
+// Config describes the rendering of a landscape.
+type Config struct {
+	// Camera returns the camera at time t.
+	Camera func(t float64) Camera
+
+	// Distance is the view distance in map pixels.
+	Distance float64
+
+	// HeightScale is the size of a height step in map pixels.
+	// 0 gives 1.
+	HeightScale float64
+
+	// Fog is the color far away. It starts at FogStart,
+	// a fraction of the distance, and is complete at the view distance.
+	Fog      color.RGBA
+	FogStart float64
+
+	// Sky is the color at the top of the screen,
+	// which fades to the fog color at the horizon.
+	Sky color.RGBA
+}
+
+// Fx is a voxel landscape effect.
+type Fx struct {
+	cfg  Config
+	m    *Map
+	draw *image.RGBA
+	// ybuf has the top line drawn for each column.
+	ybuf []int
+	w, h int
+}
+
+// New returns an effect rendering the map to w * h pixels.
+func New(w, h int, m *Map, c Config) (*Fx, error) {
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("voxel: invalid size")
+	}
+	if c.Distance <= 1 {
+		return nil, errors.New("voxel: distance must be more than 1")
+	}
+	if c.HeightScale == 0 {
+		c.HeightScale = 1
+	}
+	fx := Fx{cfg: c, m: m, w: w, h: h}
+	fx.draw = image.NewRGBA(image.Rect(0, 0, w, h))
+	fx.ybuf = make([]int, w)
+	return &fx, nil
+}
+
+// Render the effect at time t.
+func (fx *Fx) Render(t float64) image.Image {
+	return fx.Draw(fx.cfg.Camera(t))
+}
+
+// Draw the landscape seen from the camera.
+func (fx *Fx) Draw(cam Camera) image.Image {
+	m := fx.m
+	w, h := fx.w, fx.h
+	pix, stride := fx.draw.Pix, fx.draw.Stride
+	for i := range fx.ybuf {
+		fx.ybuf[i] = h
+	}
+	if cam.Horizon == 0 {
+		cam.Horizon = 0.5
+	}
+	// The view is 90 degrees wide, so the focal length is half the width.
+	focal := float64(w) / 2
+	horizon := cam.Horizon*float64(h) + math.Tan(cam.Pitch)*focal
+	sin, cos := math.Sincos(cam.Heading)
+	fog := fx.cfg.Fog
+	fogStart := fx.cfg.FogStart * fx.cfg.Distance
+	fogScale := 0.0
+	if fogStart < fx.cfg.Distance {
+		fogScale = 256 / (fx.cfg.Distance - fogStart)
+	}
+
+	// Step size grows with distance, since far lines cover less of the screen.
+	dz := 1.0
+	for z := 1.0; z < fx.cfg.Distance; z += dz {
+		// Map positions at the left and right edge of the screen.
+		lx := cam.X - z*cos + z*sin
+		ly := cam.Y - z*sin - z*cos
+		rx := cam.X + z*cos + z*sin
+		ry := cam.Y + z*sin - z*cos
+		stepX, stepY := (rx-lx)/float64(w), (ry-ly)/float64(w)
+		scale := focal * fx.cfg.HeightScale / z
+
+		// Fog amount, 0 -> 256.
+		f := 0
+		if z > fogStart {
+			f = int((z - fogStart) * fogScale)
+			if f > 256 {
+				f = 256
+			}
+		}
+
+		// Positions as 16.16 fixed point.
+		px, py := int64(lx*65536), int64(ly*65536)
+		sx, sy := int64(stepX*65536), int64(stepY*65536)
+		for x, top := range fx.ybuf {
+			mx := int(px>>16) & m.maskX
+			my := int(py>>16) & m.maskY
+			px += sx
+			py += sy
+
+			y := int((cam.Height-float64(m.heightPix[my*m.heightStep+mx]))*scale + horizon)
+			if y < 0 {
+				y = 0
+			}
+			if y >= top {
+				continue
+			}
+			c := m.colors[m.colorPix[my*m.colorStep+mx]]
+			if f > 0 {
+				c = mix(c, fog, f)
+			}
+			fx.ybuf[x] = y
+			for p := y*stride + x*4; y < top; y++ {
+				pix[p] = c.R
+				pix[p+1] = c.G
+				pix[p+2] = c.B
+				pix[p+3] = 255
+				p += stride
+			}
+		}
+		dz += 0.005 * dz
+	}
+	fx.drawSky(horizon)
+	return fx.draw
+}
+
+// drawSky fills everything above the landscape with the sky.
+func (fx *Fx) drawSky(horizon float64) {
+	pix, stride := fx.draw.Pix, fx.draw.Stride
+	sky, fog := fx.cfg.Sky, fx.cfg.Fog
+	for y := 0; y < fx.h; y++ {
+		// Fade from sky to fog at the horizon.
+		f := 256
+		if horizon > 0 {
+			f = int(float64(y) * 256 / horizon)
+			if f > 256 {
+				f = 256
+			}
+		}
+		c := mix(sky, fog, f)
+		line := pix[y*stride : y*stride+fx.w*4]
+		for x, top := range fx.ybuf {
+			if y >= top {
+				continue
+			}
+			line[x*4] = c.R
+			line[x*4+1] = c.G
+			line[x*4+2] = c.B
+			line[x*4+3] = 255
+		}
+	}
+}
+
+// mix returns a mixed with b, where f is 0 -> 256.
+func mix(a, b color.RGBA, f int) color.RGBA {
+	return color.RGBA{
+		R: uint8((int(a.R)*(256-f) + int(b.R)*f) >> 8),
+		G: uint8((int(a.G)*(256-f) + int(b.G)*f) >> 8),
+		B: uint8((int(a.B)*(256-f) + int(b.B)*f) >> 8),
+		A: 255,
+	}
+}